@@ -0,0 +1,160 @@
+package jpath
+
+import "encoding/json"
+
+type (
+	// Adapter exposes a custom document representation to compiled queries.
+	// Kind classifies a node, and the remaining methods are only called for
+	// nodes of the kind they apply to
+	Adapter interface {
+		// Kind reports which JSON value the node represents
+		Kind(node any) NodeKind
+
+		// Member returns the named member of an object node
+		Member(node any, name string) (any, bool)
+
+		// Keys returns the member names of an object node in the order
+		// wildcards and descendant segments should visit them
+		Keys(node any) []string
+
+		// Len returns the number of elements of an array node
+		Len(node any) int
+
+		// Index returns the element at idx of an array node
+		Index(node any, idx int) any
+
+		// Scalar returns the nil, bool, float64, or string value of a
+		// scalar node
+		Scalar(node any) any
+	}
+
+	// NodeKind identifies the JSON value a node represents
+	NodeKind uint8
+
+	anyModel struct{}
+
+	reflectModel struct{}
+)
+
+const (
+	NodeNull NodeKind = iota
+	NodeBool
+	NodeNumber
+	NodeString
+	NodeArray
+	NodeObject
+	NodeOther
+)
+
+var (
+	// DefaultAdapter navigates the map[string]any and []any trees produced
+	// by encoding/json, including those decoded with UseNumber. Object
+	// members are visited in sorted key order
+	DefaultAdapter Adapter = anyModel{}
+
+	// ReflectAdapter navigates arbitrary Go values the way encoding/json
+	// would marshal them
+	ReflectAdapter Adapter = reflectModel{}
+)
+
+func (anyModel) Kind(node any) NodeKind {
+	switch node.(type) {
+	case nil:
+		return NodeNull
+	case bool:
+		return NodeBool
+	case float64, int, json.Number:
+		return NodeNumber
+	case string:
+		return NodeString
+	case []any:
+		return NodeArray
+	case map[string]any:
+		return NodeObject
+	default:
+		return NodeOther
+	}
+}
+
+func (anyModel) Member(node any, name string) (any, bool) {
+	obj, ok := node.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	value, ok := obj[name]
+	return value, ok
+}
+
+func (anyModel) Keys(node any) []string {
+	obj, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return sortedKeys(obj)
+}
+
+func (anyModel) Len(node any) int {
+	if arr, ok := node.([]any); ok {
+		return len(arr)
+	}
+	return 0
+}
+
+func (anyModel) Index(node any, idx int) any {
+	return node.([]any)[idx]
+}
+
+func (anyModel) Scalar(node any) any {
+	return node
+}
+
+func (r *Registry) nodeAdapter() Adapter {
+	if r == nil || r.adapter == nil {
+		return DefaultAdapter
+	}
+	return r.adapter
+}
+
+func (r *Registry) hasCustomAdapter() bool {
+	return r != nil && r.adapter != nil
+}
+
+// toJSON converts a node into the map[string]any and []any representation
+// that the filter comparisons and built-in functions work with
+func toJSON(a Adapter, node any) any {
+	switch a.Kind(node) {
+	case NodeArray:
+		size := a.Len(node)
+		res := make([]any, size)
+		for idx := range size {
+			res[idx] = toJSON(a, a.Index(node, idx))
+		}
+		return res
+	case NodeObject:
+		keys := a.Keys(node)
+		res := make(map[string]any, len(keys))
+		for _, key := range keys {
+			value, _ := a.Member(node, key)
+			res[key] = toJSON(a, value)
+		}
+		return res
+	default:
+		return a.Scalar(node)
+	}
+}
+
+// jsonOperand converts the nodes produced by a filter operand so they
+// compare as their JSON equivalents
+func jsonOperand(a Adapter, operand FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		res := operand(ctx)
+		if !res.IsNodes {
+			return res
+		}
+		nodes := make([]any, len(res.Nodes))
+		for idx, node := range res.Nodes {
+			nodes[idx] = toJSON(a, node)
+		}
+		return NodesValue(nodes)
+	}
+}
@@ -0,0 +1,129 @@
+package jpath_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+type (
+	orderedObject struct {
+		keys   []string
+		values map[string]any
+	}
+
+	orderedAdapter struct{}
+)
+
+func ordered(pairs ...any) *orderedObject {
+	res := &orderedObject{values: map[string]any{}}
+	for idx := 0; idx < len(pairs); idx += 2 {
+		key := pairs[idx].(string)
+		res.keys = append(res.keys, key)
+		res.values[key] = pairs[idx+1]
+	}
+	return res
+}
+
+func (orderedAdapter) Kind(node any) jpath.NodeKind {
+	switch node.(type) {
+	case *orderedObject:
+		return jpath.NodeObject
+	case []any:
+		return jpath.NodeArray
+	case string:
+		return jpath.NodeString
+	case float64:
+		return jpath.NodeNumber
+	case bool:
+		return jpath.NodeBool
+	case nil:
+		return jpath.NodeNull
+	default:
+		return jpath.NodeOther
+	}
+}
+
+func (orderedAdapter) Member(node any, name string) (any, bool) {
+	value, ok := node.(*orderedObject).values[name]
+	return value, ok
+}
+
+func (orderedAdapter) Keys(node any) []string {
+	return node.(*orderedObject).keys
+}
+
+func (orderedAdapter) Len(node any) int {
+	return len(node.([]any))
+}
+
+func (orderedAdapter) Index(node any, idx int) any {
+	return node.([]any)[idx]
+}
+
+func (orderedAdapter) Scalar(node any) any {
+	return node
+}
+
+func orderedFixture() *orderedObject {
+	return ordered(
+		"zeta", 1.0,
+		"alpha", []any{
+			ordered("name", "x", "size", 3.0),
+			ordered("name", "y", "size", 12.0, "tags", []any{"a", "b"}),
+		},
+		"mid", ordered("b", "bee", "a", "ay"),
+	)
+}
+
+func TestAdapterOrder(t *testing.T) {
+	reg := jpath.NewRegistry().WithAdapter(orderedAdapter{})
+	doc := orderedFixture()
+
+	assert.Equal(t, []any{"bee", "ay"}, reg.MustQuery("$.mid.*", doc))
+	assert.Equal(t,
+		[]string{"$['zeta']", "$['alpha']", "$['mid']"},
+		reg.MustLocate("$.*", doc).Paths(),
+	)
+}
+
+func TestAdapterFilters(t *testing.T) {
+	reg := jpath.NewRegistry().WithAdapter(orderedAdapter{})
+	doc := orderedFixture()
+	alpha := doc.values["alpha"].([]any)
+
+	assertQueries(t, reg, doc, map[string][]any{
+		"$.alpha[?@.size > 5].name":          {"y"},
+		"$.alpha[?length(@.tags) == 2].name": {"y"},
+		"$..[?@ == $.mid.a]":                 {"ay"},
+		"$..[-1]":                            {alpha[1], "b"},
+	})
+
+	got := reg.MustQuery("$[?@ == $.mid]", doc)
+	if assert.Len(t, got, 1) {
+		assert.Same(t, doc.values["mid"], got[0])
+	}
+
+	def := jpath.NewRegistry().WithAdapter(jpath.DefaultAdapter)
+	assertRegistryQuery(t, def, "$.a[?@ > 1]",
+		map[string]any{"a": []any{1.0, 2.0, 3.0}}, []any{2.0, 3.0},
+	)
+}
+
+func TestAdapterStream(t *testing.T) {
+	reg := jpath.NewRegistry().WithAdapter(orderedAdapter{})
+
+	var got []any
+	err := reg.Stream(
+		"$.a[?@.b > 1].b", strings.NewReader(`{"a":[{"b":1},{"b":2}]}`),
+		func(n *jpath.Node) bool {
+			got = append(got, n.Value)
+			return true
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{2.0}, got)
+}
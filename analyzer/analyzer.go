@@ -0,0 +1,253 @@
+// Package analyzer implements a go vet pass that checks JSONPath query
+// literals at build time
+//
+// Any constant string passed as the query argument of a jpath function or
+// Registry method is parsed and validated, and every error is reported at
+// its position within the literal. Queries passed to Translate are parsed
+// in the legacy dialect, as Translate parses them. Run it with the jpathvet
+// command:
+//
+//	go vet -vettool=$(which jpathvet) ./...
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/kode4food/jpath"
+)
+
+const (
+	pkgPath    = "github.com/kode4food/jpath"
+	queryParam = "query"
+	translate  = "Translate"
+)
+
+// Analyzer checks query literals against the default registry. Calls made
+// through a Registry or Parser may use functions registered and parser
+// options set at runtime, so they accept unknown functions, every
+// extension, and the legacy dialect
+var Analyzer = newAnalyzer(jpath.NewRegistry(), false)
+
+// lenientOptions accept every syntax a Registry or Parser may be
+// configured with
+var lenientOptions = jpath.ParserOptions{
+	Dialect: jpath.DialectLegacy,
+	Extensions: jpath.ExtensionArithmetic |
+		jpath.ExtensionCompositeLiterals |
+		jpath.ExtensionMembership |
+		jpath.ExtensionRegexMatch,
+}
+
+// New returns an analyzer that checks query literals against registry and
+// its parser options, including calls made through any Registry or Parser.
+// Use it from your own vet tool when your queries call extension functions
+// or use extension syntax
+func New(registry *jpath.Registry) *analysis.Analyzer {
+	return newAnalyzer(registry, true)
+}
+
+func newAnalyzer(registry *jpath.Registry, strict bool) *analysis.Analyzer {
+	c := &checker{
+		registry: registry,
+		legacy: registry.WithParserOptions(jpath.ParserOptions{
+			Dialect: jpath.DialectLegacy,
+		}),
+		lenient: registry.WithParserOptions(lenientOptions),
+		strict:  strict,
+	}
+	return &analysis.Analyzer{
+		Name:     "jpath",
+		Doc:      "check JSONPath query literals passed to jpath",
+		URL:      "https://pkg.go.dev/github.com/kode4food/jpath/analyzer",
+		Requires: []*analysis.Analyzer{inspect.Analyzer},
+		Run:      c.run,
+	}
+}
+
+type checker struct {
+	registry *jpath.Registry
+	legacy   *jpath.Registry // parses queries the way Translate does
+	lenient  *jpath.Registry // parses any syntax a Registry may accept
+	strict   bool
+}
+
+func (c *checker) run(pass *analysis.Pass) (any, error) {
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	filter := []ast.Node{(*ast.CallExpr)(nil)}
+	ins.Preorder(filter, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn := calledFunc(pass.TypesInfo, call.Fun)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != pkgPath {
+			return
+		}
+		if !fn.Exported() {
+			return
+		}
+		sig := fn.Type().(*types.Signature)
+		idx := queryIndex(sig)
+		if idx < 0 || idx >= len(call.Args) {
+			return
+		}
+		arg := call.Args[idx]
+		tv, ok := pass.TypesInfo.Types[arg]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			return
+		}
+		reg := c.registry
+		lenient := !c.strict && usesRegistry(pass.TypesInfo, sig, call)
+		switch {
+		case fn.Name() == translate && sig.Recv() == nil:
+			reg = c.legacy
+		case lenient:
+			reg = c.lenient
+		}
+		c.check(pass, reg, arg, constant.StringVal(tv.Value), lenient)
+	})
+	return nil, nil
+}
+
+func (c *checker) check(
+	pass *analysis.Pass, reg *jpath.Registry, arg ast.Expr, query string,
+	lenient bool,
+) {
+	_, err := reg.ParseRecover(query)
+	var errs jpath.QueryErrors
+	if !errors.As(err, &errs) {
+		return
+	}
+	lit, _ := ast.Unparen(arg).(*ast.BasicLit)
+	var offsets []int
+	if lit != nil {
+		offsets = literalOffsets(lit.Value)
+	}
+	for _, qe := range errs {
+		if lenient && errors.Is(qe, jpath.ErrUnknownFunc) {
+			continue
+		}
+		d := analysis.Diagnostic{Pos: arg.Pos(), Message: message(qe)}
+		start := qe.RuneOffset
+		end := start + qe.Length
+		if offsets != nil && end < len(offsets) {
+			d.Pos = lit.Pos() + token.Pos(offsets[start])
+			if qe.Length > 0 {
+				d.End = lit.Pos() + token.Pos(offsets[end])
+			}
+		}
+		pass.Report(d)
+	}
+}
+
+func message(qe *jpath.QueryError) string {
+	msg := fmt.Sprintf("%s: %s", jpath.ErrInvalidPath, qe.Err)
+	if len(qe.Expected) == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s (expected %s)", msg, strings.Join(qe.Expected, ", "))
+}
+
+func calledFunc(info *types.Info, fun ast.Expr) *types.Func {
+	switch f := ast.Unparen(fun).(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	switch f := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		fn, _ := info.Uses[f].(*types.Func)
+		return fn
+	case *ast.SelectorExpr:
+		fn, _ := info.Uses[f.Sel].(*types.Func)
+		return fn
+	default:
+		return nil
+	}
+}
+
+// queryIndex returns the index of the query string parameter, or -1
+func queryIndex(sig *types.Signature) int {
+	str := types.Typ[types.String]
+	params := sig.Params()
+	for i := range params.Len() {
+		p := params.At(i)
+		if p.Name() == queryParam && types.Identical(p.Type(), str) {
+			return i
+		}
+	}
+	return -1
+}
+
+// usesRegistry reports whether a call may resolve functions through a
+// Registry other than the default one, either because it is a method or
+// because it is passed a Registry that isn't nil
+func usesRegistry(
+	info *types.Info, sig *types.Signature, call *ast.CallExpr,
+) bool {
+	if sig.Recv() != nil {
+		return true
+	}
+	params := sig.Params()
+	for i := range min(params.Len(), len(call.Args)) {
+		if !isRegistry(params.At(i).Type()) {
+			continue
+		}
+		if tv, ok := info.Types[call.Args[i]]; !ok || !tv.IsNil() {
+			return true
+		}
+	}
+	return false
+}
+
+func isRegistry(t types.Type) bool {
+	ptr, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgPath &&
+		obj.Name() == "Registry"
+}
+
+// literalOffsets maps each rune of a decoded string literal to the byte
+// offset in its source text where that rune begins. The final entry is
+// the offset of the closing quote
+func literalOffsets(lit string) []int {
+	if len(lit) < 2 {
+		return nil
+	}
+	body := lit[1 : len(lit)-1]
+	var res []int
+	if lit[0] == '`' {
+		for i, r := range body {
+			if r != '\r' {
+				res = append(res, i+1)
+			}
+		}
+		return append(res, len(lit)-1)
+	}
+	rest := body
+	for len(rest) > 0 {
+		res = append(res, len(lit)-1-len(rest))
+		_, _, tail, err := strconv.UnquoteChar(rest, lit[0])
+		if err != nil {
+			return nil
+		}
+		rest = tail
+	}
+	return append(res, len(lit)-1)
+}
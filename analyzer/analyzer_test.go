@@ -0,0 +1,45 @@
+package analyzer_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/kode4food/jpath"
+	"github.com/kode4food/jpath/analyzer"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "queries")
+}
+
+func TestAnalyzerRegistry(t *testing.T) {
+	reg := jpath.NewRegistry()
+	reg.MustRegisterFunction("shout", 1, func(args ...any) (any, bool) {
+		return args[0], true
+	})
+	analysistest.Run(t, analysistest.TestData(), analyzer.New(reg), "custom")
+}
+
+func TestAnalyzerPositions(t *testing.T) {
+	res := analysistest.Run(
+		t, analysistest.TestData(), analyzer.Analyzer, "positions",
+	)
+	// columns are in bytes, and diagnostics at the end of a query have no
+	// end position
+	var got []string
+	for _, r := range res {
+		for _, d := range r.Diagnostics {
+			pos := r.Pass.Fset.Position(d.Pos)
+			end := r.Pass.Fset.Position(d.End)
+			got = append(got, fmt.Sprintf(
+				"%d:%d-%d", pos.Line, pos.Column, end.Column,
+			))
+		}
+	}
+	assert.Equal(t, []string{
+		"6:25-26", "7:33-34", "8:26-27", "9:23-0", "10:29-32", "11:18-0",
+	}, got)
+}
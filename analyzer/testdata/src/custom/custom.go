@@ -0,0 +1,11 @@
+package custom
+
+import "github.com/kode4food/jpath"
+
+func queries(doc any) {
+	reg := jpath.NewRegistry()
+	reg.Query(`$[?shout(@.a)]`, doc)
+	reg.Query(`$[?nope(@.a)]`, doc)        // want `unknown function: nope`
+	reg.Query(`$[?@.a * 2 > 1]`, doc)      // want `unexpected token`
+	jpath.MustParse(`$[?shout(@.a, @.b)]`) // want `invalid function arity`
+}
@@ -0,0 +1,35 @@
+// Package jpath stubs the query API for analyzer tests
+package jpath
+
+type (
+	PathExpr struct{}
+	Registry struct{}
+	Parser   struct{}
+	Rewrite  struct{}
+)
+
+func NewRegistry() *Registry { return &Registry{} }
+
+func Parse(query string) (*PathExpr, error) { return nil, nil }
+
+func MustParse(query string) *PathExpr { return nil }
+
+func Query(query string, document any) ([]any, error) { return nil, nil }
+
+func MustQuery(query string, document any) []any { return nil }
+
+func Translate(query string) (string, []Rewrite, error) { return "", nil, nil }
+
+func QueryAs[T any](r *Registry, query string, document any) ([]T, error) {
+	return nil, nil
+}
+
+func (r *Registry) Query(query string, document any) ([]any, error) {
+	return nil, nil
+}
+
+func (r *Registry) MustParse(query string) *PathExpr { return nil }
+
+func (p *Parser) Parse(query string) (*PathExpr, error) { return nil, nil }
+
+func compile(query string) {}
@@ -0,0 +1,12 @@
+package positions
+
+import "github.com/kode4food/jpath"
+
+func queries() {
+	jpath.MustParse(`$.a[1 2]`)            // want `unexpected token`
+	jpath.MustParse("$['\u00e9'][1 2]")    // want `unexpected token`
+	jpath.MustParse("$.é[1 2]")            // want `unexpected token`
+	jpath.MustParse(`$.a[`)                // want `unexpected token`
+	jpath.MustParse(`$[?length(@.*) > 1]`) // want `singular`
+	jpath.MustParse("$.a" + "[")           // want `unexpected token`
+}
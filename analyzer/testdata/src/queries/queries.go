@@ -0,0 +1,40 @@
+package queries
+
+import "github.com/kode4food/jpath"
+
+const books = "$.store.book[?@.price < 10]"
+
+const broken = "$.store[1 2]"
+
+func queries(doc any, query string) {
+	jpath.MustParse(`$.store.book[*].title`)
+	jpath.MustParse(books)
+	jpath.MustParse(query)
+	jpath.MustQuery(`$.store[1 2]`, doc)                   // want `invalid JSONPath query: unexpected token \(expected \], ,\)`
+	jpath.Query("$[?@.a && 1, 2 3]", doc)                  // want `literal must be compared` `unexpected token`
+	jpath.Parse(broken)                                    // want `unexpected token`
+	jpath.Parse("$.a" + "[")                               // want `unexpected token`
+	jpath.MustParse(`$[?nope(@)]`)                         // want `unknown function: nope`
+	jpath.QueryAs[string](nil, `$[?length(@.*) > 1]`, doc) // want `function requires singular query`
+	jpath.QueryAs[string](nil, `$[?nope(@)]`, doc)         // want `unknown function`
+	jpath.Query(`$[?match(@.a, 'a(')]`, doc)               // want `invalid regular expression: "a\("`
+	jpath.MustParse("$[\"\"c\"\\")                         // want `unexpected token`
+	jpath.Translate(`$..book[?(@.price in [1, 2])]`)
+	jpath.Translate(`$.store[(@.length-1)]`)
+	jpath.Translate(`$.store[1 2]`)          // want `unexpected token`
+	jpath.MustParse(`$.store[(@.length-1)]`) // want `unexpected token`
+
+	reg := jpath.NewRegistry()
+	reg.Query(`$[?nope(@) && 1]`, doc) // want `literal must be compared`
+	reg.MustParse(`$[?nope(@)]`)
+	jpath.QueryAs[string](reg, `$[?nope(@)]`, doc)
+	reg.Query(`$[?@.a * 2 > 1]`, doc)
+	reg.Query(`$[?@.a in ['x'] && @.b =~ 'y']`, doc)
+	reg.Query(`$[?@.a * 2]`, doc) // want `must be compared`
+	jpath.QueryAs[string](reg, `$[?@.a * 2 > 1]`, doc)
+	jpath.Query(`$[?@.a * 2 > 1]`, doc) // want `unexpected token`
+
+	var p jpath.Parser
+	p.Parse(`$..`) // want `unexpected token`
+	p.Parse(`$[?@.a * 2 > 1]`)
+}
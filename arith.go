@@ -0,0 +1,146 @@
+package jpath
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrArithmeticMustBeCompared indicates an arithmetic result was used
+	// as a logical test rather than compared
+	ErrArithmeticMustBeCompared = errors.New(
+		"arithmetic result must be compared",
+	)
+
+	// ErrArithmeticOperand indicates an arithmetic operand can't produce
+	// a number, such as a string literal or a logical expression
+	ErrArithmeticOperand = errors.New("arithmetic requires numeric operands")
+)
+
+func (p *Parser) parseAdditive() (FilterExpr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipWS()
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticExpr(string(op), left, right)
+	}
+}
+
+func (p *Parser) parseMultiplicative() (FilterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipWS()
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticExpr(string(op), left, right)
+	}
+}
+
+// isNegation reports whether the parser is at a unary minus. A minus that
+// is directly followed by a digit begins a number literal instead
+func (p *Parser) isNegation() bool {
+	if !p.Options.extension(ExtensionArithmetic) || p.peek() != '-' {
+		return false
+	}
+	return p.pos+1 >= len(p.src) || !isDigit(p.src[p.pos+1])
+}
+
+// parseNegation parses a unary minus. Negated number literals are folded
+// into a literal, so that every way of writing a number parses the same
+func (p *Parser) parseNegation() (FilterExpr, error) {
+	start := p.pos
+	p.pos++
+	ex, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	sp := p.span(start)
+	if n, ok := numberLiteral(ex); ok {
+		return &LiteralExpr{Value: -n, Span: sp}, nil
+	}
+	return &NegateExpr{Expr: ex, Span: sp}, nil
+}
+
+func arithmeticExpr(op string, left, right FilterExpr) *ArithmeticExpr {
+	return &ArithmeticExpr{
+		Op:    op,
+		Left:  left,
+		Right: right,
+		Span: Span{
+			Start: left.span().Start,
+			End:   right.span().End,
+		},
+	}
+}
+
+// validateArithmetic validates an operand of an arithmetic operator. It
+// must be a number literal or produce a value that can be compared
+func validateArithmetic(ex FilterExpr, registry *Registry) []error {
+	switch v := ex.(type) {
+	case *LiteralExpr:
+		if _, ok := numberLiteral(v); !ok {
+			return []error{spanError(v.Span, ErrArithmeticOperand)}
+		}
+	case *UnaryExpr, *BinaryExpr:
+		return []error{spanError(ex.span(), ErrArithmeticOperand)}
+	}
+	return validateExpr(ex, contextComparisonOperand, true, registry)
+}
+
+// arithmeticOps maps each arithmetic operator to its filter function
+// builder
+var arithmeticOps = map[string]func(l, r FilterFunc) FilterFunc{
+	"+": Add,
+	"-": Sub,
+	"*": Mul,
+	"/": Div,
+	"%": Mod,
+}
+
+// arithmeticValue returns the number held by an operand's value. Anything
+// other than exactly one number, including Nothing, has no number
+func arithmeticValue(v *Value) (float64, bool) {
+	raw, ok := v.singularValue()
+	if !ok {
+		return 0, false
+	}
+	return asNumber(raw)
+}
+
+// arithmeticResult wraps the result of an arithmetic operation, which is
+// Nothing when it isn't finite, as after dividing by zero
+func arithmeticResult(n float64) *Value {
+	if math.IsInf(n, 0) || math.IsNaN(n) {
+		return ScalarValue(nothing)
+	}
+	return ScalarValue(n)
+}
+
+func numberLiteral(ex FilterExpr) (float64, bool) {
+	lit, ok := ex.(*LiteralExpr)
+	if !ok {
+		return 0, false
+	}
+	return asNumber(lit.Value)
+}
@@ -0,0 +1,59 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestArithmetic(t *testing.T) {
+	doc := []any{
+		map[string]any{"price": 10.0, "qty": 20.0, "start": 0.0, "end": 4e3},
+		map[string]any{"price": 1.5, "qty": 2.0, "name": "x"},
+		map[string]any{"price": "free", "qty": 3.0},
+	}
+	cases := map[string][]any{
+		`$[?@.price * @.qty > 100]`:        {doc[0]},
+		`$[?@.end - @.start > 3600]`:       {doc[0]},
+		`$[?@.price + 1 * 2 == 12]`:        {doc[0]},
+		`$[?(@.price + 1) * 2 == 22]`:      {doc[0]},
+		`$[?@.qty - @.price - 1 == 9]`:     {doc[0]},
+		`$[?@.qty / @.price / 2 == 1]`:     {doc[0]},
+		`$[?@.qty % 3 == 2]`:               {doc[0], doc[1]},
+		`$[?-@.price < -5]`:                {doc[0]},
+		`$[?- @.qty == -3]`:                {doc[2]},
+		`$[?@.price - -1 == 2.5]`:          {doc[1]},
+		`$[?@.price-1==0.5]`:               {doc[1]},
+		`$[?$[0].qty / @.qty == 10]`:       {doc[1]},
+		`$[?length(@.name) + 1 == 2]`:      {doc[1]},
+		`$[?length(@.qty * 2) == 1]`:       {},
+		`$[?@.qty * 2 > 3 && @.price > 1]`: {doc[0], doc[1]},
+
+		// Nothing propagates through arithmetic, and a missing result
+		// equals another missing value
+		`$[?@.price + 1 == @.missing]`: {doc[2]},
+		`$[?@.qty / 0 == @.missing]`:   doc,
+		`$[?@.qty % 0 == @.missing]`:   doc,
+		`$[?@.price * 1 < 100]`:        {doc[0], doc[1]},
+		`$[?-@.price == @.missing]`:    {doc[2]},
+	}
+	assertQueries(t, extensionRegistry(arith), doc, cases)
+}
+
+func TestArithmeticFilterFuncs(t *testing.T) {
+	ctx := &jpath.FilterCtx{}
+	two := jpath.Literal(2.0)
+	three := jpath.Literal(3)
+	str := jpath.Literal("x")
+	assert.Equal(t, jpath.ScalarValue(5.0), jpath.Add(two, three)(ctx))
+	assert.Equal(t, jpath.ScalarValue(-1.0), jpath.Sub(two, three)(ctx))
+	assert.Equal(t, jpath.ScalarValue(6.0), jpath.Mul(two, three)(ctx))
+	assert.Equal(t, jpath.ScalarValue(1.5), jpath.Div(three, two)(ctx))
+	assert.Equal(t, jpath.ScalarValue(1.0), jpath.Mod(three, two)(ctx))
+	assert.Equal(t, jpath.ScalarValue(-2.0), jpath.Neg(two)(ctx))
+	assert.True(t, jpath.Add(two, str)(ctx).IsNothing())
+	assert.True(t, jpath.Neg(str)(ctx).IsNothing())
+	assert.True(t, jpath.Div(two, jpath.Literal(0.0))(ctx).IsNothing())
+}
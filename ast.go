@@ -4,12 +4,14 @@ type (
 	// PathExpr is a parsed JSONPath expression
 	PathExpr struct {
 		Segments []*SegmentExpr
+		Span     Span
 	}
 
 	// SegmentExpr is one child or descendant traversal segment
 	SegmentExpr struct {
 		Descendant bool
 		Selectors  []*SelectorExpr
+		Span       Span
 	}
 
 	// SelectorExpr is one bracket or dot selector operation
@@ -19,6 +21,7 @@ type (
 		Index  int
 		Slice  *SliceExpr
 		Filter FilterExpr
+		Span   Span
 	}
 
 	// SliceExpr stores parsed slice bounds and step
@@ -33,35 +36,66 @@ type (
 	// FilterExpr is the marker interface for filter AST nodes
 	FilterExpr interface {
 		filterExpr()
+		span() Span
 	}
 
-	// LiteralExpr is a scalar literal in a filter expression
+	// LiteralExpr is a literal in a filter expression. Array and object
+	// literals hold a []any or map[string]any
 	LiteralExpr struct {
 		Value any
+		Span  Span
 	}
 
 	// PathValueExpr is a root or current-node relative path in a filter
 	PathValueExpr struct {
 		Absolute bool
 		Path     *PathExpr
+		Span     Span
 	}
 
 	// UnaryExpr is a unary filter expression
 	UnaryExpr struct {
 		Op   string
 		Expr FilterExpr
+		Span Span
 	}
 
 	// BinaryExpr is a binary filter expression
 	BinaryExpr struct {
 		Op          string
 		Left, Right FilterExpr
+		Span        Span
+	}
+
+	// ArithmeticExpr applies one of the binary arithmetic operators +, -,
+	// *, /, or % to two operands. It is only produced by the parser when
+	// ExtensionArithmetic is enabled
+	ArithmeticExpr struct {
+		Op          string
+		Left, Right FilterExpr
+		Span        Span
+	}
+
+	// NegateExpr is an arithmetic negation. It is only produced by the
+	// parser when ExtensionArithmetic is enabled
+	NegateExpr struct {
+		Expr FilterExpr
+		Span Span
 	}
 
 	// FuncExpr is a filter function call expression
 	FuncExpr struct {
 		Name string
 		Args []FilterExpr
+		Span Span
+	}
+
+	// Span locates a syntax tree node within the query it was parsed from.
+	// Start and End are rune offsets, with End exclusive. Nodes built by
+	// hand have a zero Span
+	Span struct {
+		Start int
+		End   int
 	}
 
 	// SelectorKind identifies the selector variant in SelectorExpr
@@ -76,8 +110,18 @@ const (
 	SelectorFilter                       // child values by filter predicate
 )
 
-func (l *LiteralExpr) filterExpr()   {}
-func (p *PathValueExpr) filterExpr() {}
-func (u *UnaryExpr) filterExpr()     {}
-func (b *BinaryExpr) filterExpr()    {}
-func (f *FuncExpr) filterExpr()      {}
+func (l *LiteralExpr) filterExpr()    {}
+func (p *PathValueExpr) filterExpr()  {}
+func (u *UnaryExpr) filterExpr()      {}
+func (b *BinaryExpr) filterExpr()     {}
+func (a *ArithmeticExpr) filterExpr() {}
+func (n *NegateExpr) filterExpr()     {}
+func (f *FuncExpr) filterExpr()       {}
+
+func (l *LiteralExpr) span() Span    { return l.Span }
+func (p *PathValueExpr) span() Span  { return p.Span }
+func (u *UnaryExpr) span() Span      { return u.Span }
+func (b *BinaryExpr) span() Span     { return b.Span }
+func (a *ArithmeticExpr) span() Span { return a.Span }
+func (n *NegateExpr) span() Span     { return n.Span }
+func (f *FuncExpr) span() Span       { return f.Span }
@@ -34,6 +34,22 @@ func BenchmarkComplianceSuite(b *testing.B) {
 		}
 	})
 
+	cached := reg.WithCache(len(cases))
+
+	b.Run("parse-compile-run-cached", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, tc := range cases {
+				result, err := cached.Query(tc.Selector, tc.Document)
+				if err != nil {
+					b.Fatalf("query failed for %q: %v", tc.Selector, err)
+				}
+				benchmarkComplianceSink = result
+			}
+		}
+	})
+
 	compiled := benchmarkCompileCases(b, reg, cases)
 
 	b.Run("run-precompiled", func(b *testing.B) {
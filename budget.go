@@ -0,0 +1,193 @@
+package jpath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type (
+	// EvalOptions bounds the work a single evaluation may perform. A zero
+	// field leaves that resource unlimited
+	EvalOptions struct {
+		// MaxVisited limits how many times a selector or descendant walk
+		// steps onto a node, including within filter subqueries
+		MaxVisited int
+
+		// MaxOutput limits how many matches the evaluation may produce
+		MaxOutput int
+
+		// MaxDepth limits how deep below the root a visited node may be
+		MaxDepth int
+
+		// MaxCalls limits how many filter function calls are made
+		MaxCalls int
+	}
+
+	// Budget identifies a resource limited by EvalOptions
+	Budget uint8
+
+	// BudgetError reports that an evaluation exceeded one of its budgets
+	BudgetError struct {
+		Budget Budget
+		Limit  int
+	}
+
+	// evalEnv carries the state of a single evaluation through the node
+	// pipeline and into the filters it runs
+	evalEnv struct {
+		root    any
+		bounded bool
+		ctx     context.Context
+		done    <-chan struct{}
+		opts    EvalOptions
+		base    int
+		visited int
+		calls   int
+		err     error
+	}
+)
+
+const (
+	BudgetVisited Budget = iota // nodes visited
+	BudgetOutput                // matches produced
+	BudgetDepth                 // depth of visited nodes
+	BudgetCalls                 // function calls
+)
+
+// ErrBudgetExceeded is matched by every *BudgetError
+var ErrBudgetExceeded = errors.New("evaluation budget exceeded")
+
+// EvalContext executes the query against a document within the budgets
+// set by opts. Traversal stops as soon as ctx is done, returning its error,
+// or as soon as a budget is exceeded, returning a *BudgetError
+func (p *NodePath) EvalContext(
+	ctx context.Context, document any, opts EvalOptions,
+) (NodeList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	env := &evalEnv{
+		root:    document,
+		bounded: true,
+		ctx:     ctx,
+		done:    ctx.Done(),
+		opts:    opts,
+	}
+	res := NodeList{}
+	p.run(RootNode(document), env, func(n *Node) bool {
+		if exceeds(opts.MaxOutput, len(res)+1) {
+			return env.exceed(BudgetOutput, opts.MaxOutput)
+		}
+		res = append(res, n)
+		return true
+	})
+	if env.err != nil {
+		return nil, env.err
+	}
+	return res, nil
+}
+
+// Error implements the error interface
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf(
+		"%s: more than %d %s", ErrBudgetExceeded, e.Limit, e.Budget,
+	)
+}
+
+// Unwrap returns ErrBudgetExceeded
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// String returns a description of the budgeted resource
+func (b Budget) String() string {
+	switch b {
+	case BudgetVisited:
+		return "visited nodes"
+	case BudgetOutput:
+		return "output nodes"
+	case BudgetDepth:
+		return "levels of depth"
+	case BudgetCalls:
+		return "function calls"
+	default:
+		return "unknown resources"
+	}
+}
+
+func newEvalEnv(root any) *evalEnv {
+	return &evalEnv{root: root}
+}
+
+// enter charges a visit to n against the budgets before yielding it
+func (e *evalEnv) enter(n *Node, yield nodeYield) bool {
+	if e.bounded && !e.visit(n) {
+		return false
+	}
+	return yield(n)
+}
+
+func (e *evalEnv) visit(n *Node) bool {
+	if e.err != nil {
+		return false
+	}
+	select {
+	case <-e.done:
+		e.err = e.ctx.Err()
+		return false
+	default:
+	}
+	e.visited++
+	if exceeds(e.opts.MaxVisited, e.visited) {
+		return e.exceed(BudgetVisited, e.opts.MaxVisited)
+	}
+	if exceeds(e.opts.MaxDepth, e.base+n.depth()) {
+		return e.exceed(BudgetDepth, e.opts.MaxDepth)
+	}
+	return true
+}
+
+// test evaluates a filter against n, so that subqueries it runs are
+// charged for depth relative to the document root
+func (e *evalEnv) test(filter FilterFunc, ctx *FilterCtx, n *Node) bool {
+	saved := e.base
+	e.base += n.depth()
+	res := toBool(filter(ctx))
+	e.base = saved
+	return res
+}
+
+func (e *evalEnv) call() bool {
+	if !e.bounded {
+		return true
+	}
+	if e.err != nil {
+		return false
+	}
+	e.calls++
+	if exceeds(e.opts.MaxCalls, e.calls) {
+		return e.exceed(BudgetCalls, e.opts.MaxCalls)
+	}
+	return true
+}
+
+func (e *evalEnv) exceed(b Budget, limit int) bool {
+	e.err = &BudgetError{Budget: b, Limit: limit}
+	return false
+}
+
+func exceeds(limit, count int) bool {
+	return limit > 0 && count > limit
+}
+
+// budgetedCall charges a function call against the evaluation's budget
+// before making it
+func budgetedCall(call FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		if ctx.env != nil && !ctx.env.call() {
+			return ScalarValue(nothing)
+		}
+		return call(ctx)
+	}
+}
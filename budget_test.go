@@ -0,0 +1,109 @@
+package jpath_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func nestedDocument(depth int) any {
+	var doc any = "leaf"
+	for range depth {
+		doc = map[string]any{"n": doc}
+	}
+	return doc
+}
+
+func TestEvalContextUnbounded(t *testing.T) {
+	doc := wideDocument()
+	path := jpath.MustCompileNodes(jpath.MustParse("$.items[?@.id < 3].id"))
+
+	got, err := path.EvalContext(context.Background(), doc, jpath.EvalOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{0.0, 1.0, 2.0}, got.Values())
+}
+
+func TestEvalContextBudgets(t *testing.T) {
+	ctx := context.Background()
+	wide := wideDocument()
+	deep := nestedDocument(50)
+
+	cases := []struct {
+		query  string
+		doc    any
+		opts   jpath.EvalOptions
+		budget jpath.Budget
+	}{
+		{"$..*", wide, jpath.EvalOptions{MaxVisited: 50}, jpath.BudgetVisited},
+		{
+			"$[?count(@..*) > 0]", map[string]any{"a": wide},
+			jpath.EvalOptions{MaxVisited: 50}, jpath.BudgetVisited,
+		},
+		{
+			"$.items[*]", wide,
+			jpath.EvalOptions{MaxOutput: 10}, jpath.BudgetOutput,
+		},
+		{"$..n", deep, jpath.EvalOptions{MaxDepth: 20}, jpath.BudgetDepth},
+		{
+			"$..[?@..leaf]", deep,
+			jpath.EvalOptions{MaxDepth: 20}, jpath.BudgetDepth,
+		},
+		{
+			"$.items[?length(@.tags) > 1]", wide,
+			jpath.EvalOptions{MaxCalls: 5}, jpath.BudgetCalls,
+		},
+	}
+	for _, tc := range cases {
+		_, err := jpath.QueryContext(ctx, tc.query, tc.doc, tc.opts)
+		assert.ErrorIs(t, err, jpath.ErrBudgetExceeded, tc.query)
+		var be *jpath.BudgetError
+		if assert.ErrorAs(t, err, &be, tc.query) {
+			assert.Equal(t, tc.budget, be.Budget, tc.query)
+		}
+	}
+
+	got, err := jpath.QueryContext(ctx, "$..n", nestedDocument(5),
+		jpath.EvalOptions{MaxDepth: 5, MaxVisited: 10, MaxOutput: 5},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, got, 5)
+
+	_, err = jpath.QueryContext(ctx, "$.items[*]", wide,
+		jpath.EvalOptions{MaxOutput: 1},
+	)
+	assert.EqualError(t, err,
+		"evaluation budget exceeded: more than 1 output nodes",
+	)
+}
+
+func TestEvalContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := jpath.QueryContext(ctx, "$..*", wideDocument(),
+		jpath.EvalOptions{},
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	reg := jpath.NewRegistry()
+	reg.MustRegisterFunction("stop", 1, func(args ...any) (any, bool) {
+		calls++
+		if calls == 10 {
+			cancel()
+		}
+		return true, true
+	})
+	_, err = reg.QueryContext(ctx, "$..[?stop(@)]", wideDocument(),
+		jpath.EvalOptions{},
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, calls, 20)
+
+	_, err = reg.QueryContext(ctx, "$[", nil, jpath.EvalOptions{})
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+}
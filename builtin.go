@@ -12,24 +12,43 @@ const regexCacheLimit = 4096
 var (
 	defaultFunctions = map[string]*FunctionDefinition{
 		"length": {
-			Validate: validateLengthFunction,
-			Eval:     evalLength,
+			Signature: &Signature{
+				Params: []FunctionType{ValueType},
+				Result: ValueType,
+			},
+			Eval: evalLength,
 		},
 		"count": {
-			Validate: validateCountFunction,
-			Eval:     evalCount,
+			Signature: &Signature{
+				Params: []FunctionType{NodesType},
+				Result: ValueType,
+			},
+			Eval: evalCount,
 		},
 		"value": {
-			Validate: validateValueFunction,
-			Eval:     evalValueFunc,
+			Signature: &Signature{
+				Params: []FunctionType{NodesType},
+				Result: ValueType,
+			},
+			Eval: evalValueFunc,
 		},
 		"match": {
-			Validate: validateMatchSearchFunction,
+			Signature: &Signature{
+				Params: []FunctionType{ValueType, ValueType},
+				Result: LogicalType,
+			},
+			Validate: validatePatternArg(true),
 			Eval:     evalFullMatch,
+			prepare:  preparePatternCall(true),
 		},
 		"search": {
-			Validate: validateMatchSearchFunction,
+			Signature: &Signature{
+				Params: []FunctionType{ValueType, ValueType},
+				Result: LogicalType,
+			},
+			Validate: validatePatternArg(false),
 			Eval:     evalPartialMatch,
+			prepare:  preparePatternCall(false),
 		},
 	}
 
@@ -40,24 +59,6 @@ func registerDefaultFunctions(r *Registry) {
 	maps.Copy(r.functions, defaultFunctions)
 }
 
-func validateLengthFunction(
-	args []FilterExpr, use FunctionUse, inComparison bool,
-) error {
-	return validateUnaryComparedSingular("length", args, use, inComparison)
-}
-
-func validateCountFunction(
-	args []FilterExpr, use FunctionUse, inComparison bool,
-) error {
-	return validateUnaryComparedReq("count", args, use, inComparison)
-}
-
-func validateValueFunction(
-	args []FilterExpr, use FunctionUse, inComparison bool,
-) error {
-	return validateUnaryComparedReq("value", args, use, inComparison)
-}
-
 func evalLength(args []*Value) *Value {
 	if len(args) != 1 {
 		return ScalarValue(nothing)
@@ -115,7 +116,7 @@ func evalFullMatch(args []*Value) *Value {
 	if !ok {
 		return ScalarValue(nothing)
 	}
-	return evalPatternMatch(left, "^(?:"+pattern+")$")
+	return evalPatternMatch(left, pattern, true)
 }
 
 func evalPartialMatch(args []*Value) *Value {
@@ -123,7 +124,7 @@ func evalPartialMatch(args []*Value) *Value {
 	if !ok {
 		return ScalarValue(nothing)
 	}
-	return evalPatternMatch(left, pattern)
+	return evalPatternMatch(left, pattern, false)
 }
 
 func evalMatchArguments(args []*Value) (string, string, bool) {
@@ -149,18 +150,10 @@ func evalMatchArguments(args []*Value) (string, string, bool) {
 	return left, pattern, true
 }
 
-func evalPatternMatch(left, pattern string) *Value {
-	pattern = normalizeDotPattern(pattern)
-	re, ok := compileMatchPattern(pattern)
-	if !ok {
+func evalPatternMatch(left, pattern string, full bool) *Value {
+	re, err := compilePattern(pattern, full)
+	if err != nil {
 		return ScalarValue(nothing)
 	}
 	return ScalarValue(re.MatchString(left))
 }
-
-func compileMatchPattern(pattern string) (*regexp.Regexp, bool) {
-	re, err := regexCache.Get(pattern, func() (*regexp.Regexp, error) {
-		return regexp.Compile(pattern)
-	})
-	return re, err == nil && re != nil
-}
@@ -0,0 +1,105 @@
+package jpath
+
+import (
+	"sync/atomic"
+
+	"github.com/kode4food/lru"
+)
+
+type (
+	// CacheStats reports the activity of a registry's compiled query cache
+	CacheStats struct {
+		Hits   uint64
+		Misses uint64
+	}
+
+	// queryCache holds compiled queries keyed by query text. Its entries
+	// are discarded whenever the functions they were compiled against
+	// change
+	queryCache struct {
+		size    int
+		entries atomic.Pointer[cacheEntries]
+		hits    atomic.Uint64
+		misses  atomic.Uint64
+	}
+
+	cacheEntries struct {
+		paths *lru.Cache[Path]
+		nodes *lru.Cache[*NodePath]
+	}
+)
+
+// WithCache returns a copy of this registry that keeps up to size compiled
+// queries of each kind, keyed by query text, so that repeated calls to
+// Query, Locate, and the other query-string methods skip parsing and
+// compiling. Registering a function on the registry empties its cache
+func (r *Registry) WithCache(size int) *Registry {
+	res := r.Clone()
+	res.cache = newQueryCache(size)
+	return res
+}
+
+// CacheStats returns the number of cache hits and misses since the cache
+// was created. It returns zero stats for a registry without a cache
+func (r *Registry) CacheStats() CacheStats {
+	if r.cache == nil {
+		return CacheStats{}
+	}
+	return CacheStats{
+		Hits:   r.cache.hits.Load(),
+		Misses: r.cache.misses.Load(),
+	}
+}
+
+func newQueryCache(size int) *queryCache {
+	res := &queryCache{size: size}
+	res.reset()
+	return res
+}
+
+func (c *queryCache) reset() {
+	c.entries.Store(&cacheEntries{
+		paths: lru.NewCache[Path](c.size),
+		nodes: lru.NewCache[*NodePath](c.size),
+	})
+}
+
+func cachedQuery[T any](
+	c *queryCache, entries *lru.Cache[T], query string,
+	compile func() (T, error),
+) (T, error) {
+	hit := true
+	res, err := entries.Get(query, func() (T, error) {
+		hit = false
+		return compile()
+	})
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return res, err
+}
+
+func (r *Registry) compileQuery(query string) (Path, error) {
+	if r.cache == nil {
+		return r.compileQueryText(query)
+	}
+	return cachedQuery(
+		r.cache, r.cache.entries.Load().paths, query, func() (Path, error) {
+			return r.compileQueryText(query)
+		},
+	)
+}
+
+func (r *Registry) compileNodeQuery(query string) (*NodePath, error) {
+	if r.cache == nil {
+		return r.compileNodeQueryText(query)
+	}
+	return cachedQuery(
+		r.cache, r.cache.entries.Load().nodes, query,
+		func() (*NodePath, error) {
+			return r.compileNodeQueryText(query)
+		},
+	)
+}
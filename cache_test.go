@@ -0,0 +1,66 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestQueryCache(t *testing.T) {
+	reg := jpath.NewRegistry().WithCache(2)
+	doc := map[string]any{"a": 1.0, "b": 2.0, "c": 3.0}
+
+	for range 3 {
+		got, err := reg.Query("$.a", doc)
+		assert.NoError(t, err)
+		assert.Equal(t, []any{1.0}, got)
+	}
+	assert.Equal(t, jpath.CacheStats{Hits: 2, Misses: 1}, reg.CacheStats())
+
+	reg.MustQuery("$.b", doc)
+	reg.MustQuery("$.c", doc)
+	reg.MustQuery("$.a", doc)
+	assert.Equal(t, jpath.CacheStats{Hits: 2, Misses: 4}, reg.CacheStats())
+
+	_, err := reg.Locate("$.c", doc)
+	assert.NoError(t, err)
+	_, err = reg.Exists("$.c", doc)
+	assert.NoError(t, err)
+	assert.Equal(t, jpath.CacheStats{Hits: 3, Misses: 5}, reg.CacheStats())
+}
+
+func TestQueryCacheErrors(t *testing.T) {
+	reg := jpath.NewRegistry().WithCache(8)
+
+	for range 2 {
+		_, err := reg.Query("$[", nil)
+		assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+	}
+	assert.Equal(t, jpath.CacheStats{Misses: 2}, reg.CacheStats())
+}
+
+func TestQueryCacheInvalidation(t *testing.T) {
+	reg := jpath.NewRegistry().WithCache(8)
+	doc := []any{"a", "b"}
+
+	_, err := reg.Query("$[?yes(@)]", doc)
+	assert.ErrorIs(t, err, jpath.ErrUnknownFunc)
+
+	reg.MustQuery("$[0]", doc)
+	reg.MustRegisterFunction("yes", 1, func(...any) (any, bool) {
+		return true, true
+	})
+	assert.Equal(t, []any{"a", "b"}, reg.MustQuery("$[?yes(@)]", doc))
+	reg.MustQuery("$[0]", doc)
+	assert.Equal(t, jpath.CacheStats{Misses: 4}, reg.CacheStats())
+
+	clone := reg.Clone()
+	clone.MustQuery("$[0]", doc)
+	clone.MustQuery("$[0]", doc)
+	assert.Equal(t, jpath.CacheStats{Hits: 1, Misses: 1}, clone.CacheStats())
+	assert.Equal(t, jpath.CacheStats{Misses: 4}, reg.CacheStats())
+
+	assert.Equal(t, jpath.CacheStats{}, jpath.NewRegistry().CacheStats())
+}
@@ -0,0 +1,234 @@
+// Package cli implements the jpath command line tool
+//
+// Build a tool with extension functions by registering them on a registry
+// and passing it to Run from your own main package
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kode4food/jpath"
+)
+
+type (
+	// Format selects how matches are written
+	Format string
+
+	command struct {
+		registry *jpath.Registry
+		queries  []string
+		format   Format
+		ndjson   bool
+		files    []string
+		stdin    io.Reader
+		stdout   io.Writer
+		matches  []any
+		matched  bool
+	}
+
+	queryList []string
+)
+
+const (
+	FormatArray Format = "array" // a single JSON array of all matches
+	FormatLines Format = "lines" // one JSON value per line
+	FormatPaths Format = "paths" // normalized path and value per line
+)
+
+// Exit codes returned by Run
+const (
+	ExitMatch   = 0 // at least one query matched
+	ExitNoMatch = 1 // no query matched anything
+	ExitError   = 2 // bad arguments, an invalid query, or unreadable input
+)
+
+const usage = `usage: jpath [flags] QUERY [FILE...]
+       jpath [flags] -e QUERY [-e QUERY...] [FILE...]
+
+Runs JSONPath queries against JSON documents read from each FILE, or from
+standard input when no FILE (or -) is given. Exits with 0 when something
+matched, 1 when nothing did, and 2 on error
+
+`
+
+// Run executes the jpath command with the provided arguments, excluding
+// the program name, and returns its exit code. Queries are compiled using
+// registry, so any extension functions registered on it are available
+func Run(
+	registry *jpath.Registry, args []string, stdin io.Reader,
+	stdout, stderr io.Writer,
+) int {
+	cmd, err := parseArgs(registry, args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitMatch
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jpath: %s\n", err)
+		return ExitError
+	}
+	cmd.stdin = stdin
+	cmd.stdout = stdout
+	if err := cmd.run(); err != nil {
+		printError(stderr, err)
+		return ExitError
+	}
+	if !cmd.matched {
+		return ExitNoMatch
+	}
+	return ExitMatch
+}
+
+func parseArgs(
+	registry *jpath.Registry, args []string, stderr io.Writer,
+) (*command, error) {
+	cmd := &command{registry: registry}
+	fs := flag.NewFlagSet("jpath", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	queries := queryList{}
+	fs.Var(&queries, "e", "query to run (repeatable)")
+	format := fs.String("o", string(FormatArray),
+		"output format: array, lines, or paths",
+	)
+	fs.BoolVar(&cmd.ndjson, "ndjson", false,
+		"read newline-delimited JSON values from each input",
+	)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cmd.files = fs.Args()
+	if len(queries) == 0 {
+		if len(cmd.files) == 0 {
+			fs.Usage()
+			return nil, errors.New("missing query")
+		}
+		queries = append(queries, cmd.files[0])
+		cmd.files = cmd.files[1:]
+	}
+	cmd.queries = queries
+	switch f := Format(*format); f {
+	case FormatArray, FormatLines, FormatPaths:
+		cmd.format = f
+	default:
+		return nil, fmt.Errorf("unknown output format: %s", *format)
+	}
+	return cmd, nil
+}
+
+func (c *command) run() error {
+	paths := make([]*jpath.NodePath, len(c.queries))
+	for idx, query := range c.queries {
+		path, err := c.registry.CompileQuery(query)
+		if err != nil {
+			return err
+		}
+		paths[idx] = path
+	}
+	if len(c.files) == 0 {
+		c.files = []string{"-"}
+	}
+	for _, name := range c.files {
+		if err := c.runFile(name, paths); err != nil {
+			return err
+		}
+	}
+	if c.format != FormatArray {
+		return nil
+	}
+	if c.matches == nil {
+		return c.write([]any{})
+	}
+	return c.write(c.matches)
+}
+
+func (c *command) runFile(name string, paths []*jpath.NodePath) error {
+	in := c.stdin
+	if name == "-" {
+		name = "stdin"
+	} else {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+	for count := 0; ; count++ {
+		var doc any
+		err := dec.Decode(&doc)
+		switch {
+		case err == io.EOF && (count > 0 || c.ndjson):
+			return nil
+		case err != nil:
+			return fmt.Errorf("%s: %w", name, err)
+		case count > 0 && !c.ndjson:
+			return fmt.Errorf("%s: multiple JSON values, use -ndjson", name)
+		}
+		if err := c.evaluate(doc, paths); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *command) evaluate(doc any, paths []*jpath.NodePath) error {
+	for _, path := range paths {
+		for _, n := range path.Locate(doc) {
+			c.matched = true
+			if err := c.emit(n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *command) emit(n *jpath.Node) error {
+	switch c.format {
+	case FormatLines:
+		return c.write(n.Value)
+	case FormatPaths:
+		if _, err := fmt.Fprintf(c.stdout, "%s\t", n.Path()); err != nil {
+			return err
+		}
+		return c.write(n.Value)
+	default:
+		c.matches = append(c.matches, n.Value)
+		return nil
+	}
+}
+
+func (c *command) write(value any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(value)
+}
+
+// printError writes err to w, following a query error with the query and
+// a caret marking where it went wrong
+func printError(w io.Writer, err error) {
+	fmt.Fprintf(w, "jpath: %s\n", err)
+	var qe *jpath.QueryError
+	if errors.As(err, &qe) {
+		fmt.Fprintf(w, "%s\n", qe.Snippet())
+	}
+}
+
+func (q *queryList) String() string {
+	return strings.Join(*q, ", ")
+}
+
+func (q *queryList) Set(query string) error {
+	*q = append(*q, query)
+	return nil
+}
@@ -0,0 +1,145 @@
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+	"github.com/kode4food/jpath/cli"
+)
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+func run(reg *jpath.Registry, stdin string, args ...string) result {
+	var stdout, stderr bytes.Buffer
+	code := cli.Run(reg, args, strings.NewReader(stdin), &stdout, &stderr)
+	return result{code, stdout.String(), stderr.String()}
+}
+
+func TestRunFormats(t *testing.T) {
+	reg := jpath.NewRegistry()
+	doc := `{"a": [1, "<b>", {"c": null}]}`
+
+	res := run(reg, doc, "$.a[*]")
+	assert.Equal(t, cli.ExitMatch, res.code)
+	assert.Equal(t, "[1,\"<b>\",{\"c\":null}]\n", res.stdout)
+
+	res = run(reg, doc, "-o", "lines", "$.a[*]")
+	assert.Equal(t, "1\n\"<b>\"\n{\"c\":null}\n", res.stdout)
+
+	res = run(reg, doc, "-o", "paths", "$..c")
+	assert.Equal(t, "$['a'][2]['c']\tnull\n", res.stdout)
+
+	res = run(reg, doc, "-e", "$.a[0]", "-e", "$.a[1]")
+	assert.Equal(t, "[1,\"<b>\"]\n", res.stdout)
+}
+
+func TestRunLargeNumbers(t *testing.T) {
+	reg := jpath.NewRegistry()
+	doc := `[12345678901234567890, 1.50, 3]`
+
+	res := run(reg, doc, "$[?@ > 2]")
+	assert.Equal(t, cli.ExitMatch, res.code)
+	assert.Equal(t, "[12345678901234567890,3]\n", res.stdout)
+
+	res = run(reg, doc, "-o", "lines", "$[?@ == 1.5]")
+	assert.Equal(t, "1.50\n", res.stdout)
+}
+
+func TestRunNoMatch(t *testing.T) {
+	reg := jpath.NewRegistry()
+
+	res := run(reg, `{"a": 1}`, "$.b")
+	assert.Equal(t, cli.ExitNoMatch, res.code)
+	assert.Equal(t, "[]\n", res.stdout)
+
+	res = run(reg, `{"a": 1}`, "-o", "lines", "$.b")
+	assert.Equal(t, cli.ExitNoMatch, res.code)
+	assert.Empty(t, res.stdout)
+}
+
+func TestRunNDJSON(t *testing.T) {
+	reg := jpath.NewRegistry()
+	input := "{\"id\": 1}\n{\"id\": 2}\n{\"other\": 3}\n"
+
+	res := run(reg, input, "-ndjson", "-o", "lines", "$.id")
+	assert.Equal(t, cli.ExitMatch, res.code)
+	assert.Equal(t, "1\n2\n", res.stdout)
+
+	res = run(reg, input, "$.id")
+	assert.Equal(t, cli.ExitError, res.code)
+	assert.Contains(t, res.stderr, "use -ndjson")
+
+	res = run(reg, "", "-ndjson", "$.id")
+	assert.Equal(t, cli.ExitNoMatch, res.code)
+}
+
+func TestRunFiles(t *testing.T) {
+	reg := jpath.NewRegistry()
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	second := filepath.Join(dir, "second.json")
+	assert.NoError(t, os.WriteFile(first, []byte(`{"v": "a"}`), 0o600))
+	assert.NoError(t, os.WriteFile(second, []byte(`{"v": "b"}`), 0o600))
+
+	res := run(reg, `{"v": "c"}`, "$.v", first, "-", second)
+	assert.Equal(t, cli.ExitMatch, res.code)
+	assert.Equal(t, "[\"a\",\"c\",\"b\"]\n", res.stdout)
+
+	res = run(reg, "", "$.v", filepath.Join(dir, "missing.json"))
+	assert.Equal(t, cli.ExitError, res.code)
+	assert.Contains(t, res.stderr, "missing.json")
+}
+
+func TestRunErrors(t *testing.T) {
+	reg := jpath.NewRegistry()
+
+	res := run(reg, "{}")
+	assert.Equal(t, cli.ExitError, res.code)
+	assert.Contains(t, res.stderr, "missing query")
+
+	res = run(reg, "{}", "$[")
+	assert.Equal(t, cli.ExitError, res.code)
+	assert.Contains(t, res.stderr, "invalid JSONPath query")
+
+	res = run(reg, "{}", "$[?nope(@)]")
+	assert.Equal(t, cli.ExitError, res.code)
+	assert.Contains(t, res.stderr, "unknown function: nope")
+	assert.Contains(t, res.stderr, "\n$[?nope(@)]\n   ^^^^^^^\n")
+
+	res = run(reg, "{}", "-o", "yaml", "$")
+	assert.Equal(t, cli.ExitError, res.code)
+	assert.Contains(t, res.stderr, "unknown output format")
+
+	res = run(reg, "{", "$")
+	assert.Equal(t, cli.ExitError, res.code)
+	assert.Contains(t, res.stderr, "stdin")
+
+	res = run(reg, "{}", "-h")
+	assert.Equal(t, cli.ExitMatch, res.code)
+	assert.Contains(t, res.stderr, "usage: jpath")
+}
+
+func TestRunCustomRegistry(t *testing.T) {
+	reg := jpath.NewRegistry()
+	reg.MustRegisterFunction("upper", 1, func(args ...any) (any, bool) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, false
+		}
+		return strings.ToUpper(s), true
+	})
+
+	res := run(reg, `["a", "B"]`, "$[?upper(@) == @]")
+	assert.Equal(t, cli.ExitMatch, res.code)
+	assert.Equal(t, "[\"B\"]\n", res.stdout)
+}
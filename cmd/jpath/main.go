@@ -0,0 +1,15 @@
+// Command jpath runs JSONPath queries against JSON documents
+package main
+
+import (
+	"os"
+
+	"github.com/kode4food/jpath"
+	"github.com/kode4food/jpath/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(
+		jpath.NewRegistry(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr,
+	))
+}
@@ -0,0 +1,13 @@
+// Command jpathvet checks JSONPath query literals in Go source. Run it
+// through go vet with -vettool
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/kode4food/jpath/analyzer"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
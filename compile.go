@@ -1,6 +1,10 @@
 package jpath
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
 
 // Compiler compiles parsed JSONPath syntax trees into runnable programs
 type Compiler struct {
@@ -17,14 +21,34 @@ func (c *Compiler) Compile(path *PathExpr) (Path, error) {
 	return compilePath(path, c.registry)
 }
 
+// CompileNodes compiles a parsed PathExpr into an executable NodePath
+func (c *Compiler) CompileNodes(path *PathExpr) (*NodePath, error) {
+	return compileNodePath(path, c.registry)
+}
+
+// CompileStream compiles a parsed PathExpr into an executable StreamPath
+func (c *Compiler) CompileStream(path *PathExpr) (*StreamPath, error) {
+	return compileStreamPath(path, c.registry)
+}
+
 func compilePath(path *PathExpr, registry *Registry) (Path, error) {
 	if err := validatePath(path, registry); err != nil {
 		return nil, err
 	}
-	return makePath(path, registry)
+	return makePath(Optimize(path), registry)
+}
+
+func compileNodePath(path *PathExpr, registry *Registry) (*NodePath, error) {
+	if err := validatePath(path, registry); err != nil {
+		return nil, err
+	}
+	return makeNodePath(Optimize(path), registry)
 }
 
 func makePath(path *PathExpr, registry *Registry) (Path, error) {
+	if registry.hasCustomAdapter() {
+		return makeModelPath(path, registry)
+	}
 	segments := make([]SegmentFunc, len(path.Segments))
 	for idx, segment := range path.Segments {
 		compiled, err := compileSegment(segment, registry)
@@ -77,7 +101,83 @@ func compileSelector(
 		return SelectFilter(filter), nil
 
 	default:
-		return nil, fmt.Errorf("unknown selector kind")
+		return nil, spanError(sel.Span, errors.New("unknown selector kind"))
+	}
+}
+
+func makeModelPath(path *PathExpr, registry *Registry) (Path, error) {
+	np, err := makeNodePath(path, registry)
+	if err != nil {
+		return nil, err
+	}
+	return func(document any) []any {
+		res := make([]any, 0)
+		np.each(document, func(n *Node) bool {
+			res = append(res, n.Value)
+			return true
+		})
+		return res
+	}, nil
+}
+
+func makeNodePath(path *PathExpr, registry *Registry) (*NodePath, error) {
+	segments := make([]nodeSegment, len(path.Segments))
+	for idx, segment := range path.Segments {
+		compiled, err := compileNodeSegment(segment, registry)
+		if err != nil {
+			return nil, err
+		}
+		segments[idx] = compiled
+	}
+	res := composeNodePath(segments)
+	if len(path.Segments) == 0 {
+		return res, nil
+	}
+	last := path.Segments[len(path.Segments)-1]
+	return res.withTrailingNames(segments, trailingNames(last)), nil
+}
+
+func compileNodeSegment(
+	segment *SegmentExpr, registry *Registry,
+) (nodeSegment, error) {
+	selectors := make([]nodeSelector, len(segment.Selectors))
+	for idx, selector := range segment.Selectors {
+		compiled, err := compileNodeSelector(selector, registry)
+		if err != nil {
+			return nil, err
+		}
+		selectors[idx] = compiled
+	}
+	a := registry.nodeAdapter()
+	return composeNodeSegment(a, selectors, segment.Descendant), nil
+}
+
+func compileNodeSelector(
+	sel *SelectorExpr, registry *Registry,
+) (nodeSelector, error) {
+	a := registry.nodeAdapter()
+	switch sel.Kind {
+	case SelectorName:
+		return locateName(a, sel.Name), nil
+
+	case SelectorIndex:
+		return locateIndex(a, sel.Index), nil
+
+	case SelectorWildcard:
+		return locateWildcard(a), nil
+
+	case SelectorSlice:
+		return locateSlice(a, sel.Slice), nil
+
+	case SelectorFilter:
+		filter, err := compileFilter(sel.Filter, registry)
+		if err != nil {
+			return nil, err
+		}
+		return locateFilter(a, filter), nil
+
+	default:
+		return nil, spanError(sel.Span, errors.New("unknown selector kind"))
 	}
 }
 
@@ -87,14 +187,14 @@ func compileFilter(expr FilterExpr, registry *Registry) (FilterFunc, error) {
 		return Literal(v.Value), nil
 
 	case *PathValueExpr:
-		path, err := makePath(v.Path, registry)
+		if !registry.hasCustomAdapter() && !fansOut(v.Path) {
+			return compileOperand(v, registry)
+		}
+		np, err := makeNodePath(v.Path, registry)
 		if err != nil {
 			return nil, err
 		}
-		if v.Absolute {
-			return PathRoot(path), nil
-		}
-		return PathCurrent(path), nil
+		return hoistRoot(existsTest(np, v.Absolute), v.Absolute), nil
 
 	case *UnaryExpr:
 		exprFunc, err := compileFilter(v.Expr, registry)
@@ -104,14 +204,20 @@ func compileFilter(expr FilterExpr, registry *Registry) (FilterFunc, error) {
 		if v.Op == "!" {
 			return Not(exprFunc), nil
 		}
-		return nil, fmt.Errorf("unknown unary operator: %s", v.Op)
+		return nil, spanError(
+			v.Span, fmt.Errorf("unknown unary operator: %s", v.Op),
+		)
 
 	case *BinaryExpr:
-		leftFunc, err := compileFilter(v.Left, registry)
+		compileSide := compileOperand
+		if v.Op == "&&" || v.Op == "||" {
+			compileSide = compileFilter
+		}
+		leftFunc, err := compileSide(v.Left, registry)
 		if err != nil {
 			return nil, err
 		}
-		rightFunc, err := compileFilter(v.Right, registry)
+		rightFunc, err := compileSide(v.Right, registry)
 		if err != nil {
 			return nil, err
 		}
@@ -132,26 +238,202 @@ func compileFilter(expr FilterExpr, registry *Registry) (FilterFunc, error) {
 			return Gt(leftFunc, rightFunc), nil
 		case ">=":
 			return Ge(leftFunc, rightFunc), nil
+		case "in":
+			return In(leftFunc, rightFunc), nil
+		case "nin":
+			return Nin(leftFunc, rightFunc), nil
+		case "subsetof":
+			return SubsetOf(leftFunc, rightFunc), nil
+		case "anyof":
+			return AnyOf(leftFunc, rightFunc), nil
+		case "=~":
+			re, ok, err := literalPattern(v.Right, true)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return matchPattern(leftFunc, re), nil
+			}
+			return Match(leftFunc, rightFunc), nil
 		default:
-			return nil, fmt.Errorf("unknown operator: %s", v.Op)
+			return nil, spanError(
+				v.Span, fmt.Errorf("unknown operator: %s", v.Op),
+			)
 		}
 
+	case *ArithmeticExpr:
+		build, ok := arithmeticOps[v.Op]
+		if !ok {
+			return nil, spanError(
+				v.Span, fmt.Errorf("unknown operator: %s", v.Op),
+			)
+		}
+		leftFunc, err := compileOperand(v.Left, registry)
+		if err != nil {
+			return nil, err
+		}
+		rightFunc, err := compileOperand(v.Right, registry)
+		if err != nil {
+			return nil, err
+		}
+		return build(leftFunc, rightFunc), nil
+
+	case *NegateExpr:
+		exprFunc, err := compileOperand(v.Expr, registry)
+		if err != nil {
+			return nil, err
+		}
+		return Neg(exprFunc), nil
+
 	case *FuncExpr:
+		def, ok := registry.function(v.Name)
+		if !ok {
+			return nil, spanError(
+				v.Span, fmt.Errorf("%w: %s", ErrUnknownFunc, v.Name),
+			)
+		}
 		args := make([]FilterFunc, len(v.Args))
 		for idx, arg := range v.Args {
-			compiled, err := compileFilter(arg, registry)
+			compiled, err := compileOperand(arg, registry)
 			if err != nil {
 				return nil, err
 			}
+			if def.Signature != nil {
+				compiled = convertArg(def.Signature.Params[idx], compiled)
+			}
 			args[idx] = compiled
 		}
-		def, ok := registry.function(v.Name)
-		if !ok {
-			return nil, fmt.Errorf("%w: %s", ErrUnknownFunc, v.Name)
+		eval := def.Eval
+		if def.prepare != nil {
+			prepared, err := def.prepare(v.Args)
+			if err != nil {
+				return nil, err
+			}
+			if prepared != nil {
+				eval = prepared
+			}
 		}
-		return Call(def.Eval, args...), nil
+		return budgetedCall(Call(eval, args...)), nil
 
 	default:
 		return nil, fmt.Errorf("unknown filter expression")
 	}
 }
+
+// compileOperand compiles a filter expression whose result is consumed as
+// a value. Nodes reached through a custom adapter are converted to their JSON
+// equivalents, so comparisons and functions see them as if marshaled
+func compileOperand(expr FilterExpr, registry *Registry) (FilterFunc, error) {
+	v, ok := expr.(*PathValueExpr)
+	if !ok {
+		return compileFilter(expr, registry)
+	}
+	if registry.hasCustomAdapter() {
+		np, err := makeNodePath(v.Path, registry)
+		if err != nil {
+			return nil, err
+		}
+		res := pathOperand(nil, func() *NodePath { return np }, v.Absolute)
+		res = jsonOperand(registry.nodeAdapter(), res)
+		return hoistRoot(res, v.Absolute), nil
+	}
+	fast, err := makePath(v.Path, registry)
+	if err != nil {
+		return nil, err
+	}
+	// the NodePath is only needed by bounded evaluations, and compiles
+	// without error whenever the fast Path does
+	np := sync.OnceValue(func() *NodePath {
+		res, _ := makeNodePath(v.Path, registry)
+		return res
+	})
+	return hoistRoot(pathOperand(fast, np, v.Absolute), v.Absolute), nil
+}
+
+// pathOperand evaluates a query whose matched values are consumed by a
+// comparison or function. Unbounded evaluations use the fast Path when one
+// is available, while bounded ones run the NodePath so that every node it
+// visits is charged against the evaluation's budgets
+func pathOperand(
+	fast Path, np func() *NodePath, absolute bool,
+) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		env := ctx.env
+		if fast != nil && (env == nil || !env.bounded) {
+			if absolute {
+				return NodesValue(fast(ctx.Root))
+			}
+			return NodesValue(fast(ctx.Current))
+		}
+		res := make([]any, 0)
+		runSubquery(ctx, np(), absolute, func(n *Node) bool {
+			res = append(res, n.Value)
+			return true
+		})
+		return NodesValue(res)
+	}
+}
+
+// existsTest compiles a query used as a logical test. Only the presence of
+// a match matters, so evaluation stops at the first node found
+func existsTest(np *NodePath, absolute bool) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		found := false
+		runSubquery(ctx, np, absolute, func(*Node) bool {
+			found = true
+			return false
+		})
+		return ScalarValue(found)
+	}
+}
+
+// hoistRoot evaluates a root-relative subquery once per filter context
+// rather than once per tested child, as its result cannot change between
+// them. Current-relative subqueries are returned as they are
+func hoistRoot(eval FilterFunc, absolute bool) FilterFunc {
+	if !absolute {
+		return eval
+	}
+	slot := &hoistSlot{}
+	return func(ctx *FilterCtx) *Value {
+		if res, ok := ctx.hoisted[slot]; ok {
+			return res
+		}
+		res := eval(ctx)
+		if ctx.hoisted == nil {
+			ctx.hoisted = map[*hoistSlot]*Value{}
+		}
+		ctx.hoisted[slot] = res
+		return res
+	}
+}
+
+func runSubquery(
+	ctx *FilterCtx, np *NodePath, absolute bool, yield nodeYield,
+) {
+	env := ctx.env
+	if env == nil {
+		env = newEvalEnv(ctx.Root)
+	}
+	if absolute {
+		np.runFrom(ctx.Root, 0, env, yield)
+		return
+	}
+	np.runFrom(ctx.Current, env.base, env, yield)
+}
+
+// fansOut reports whether a query can match more than one node, in which
+// case stopping at the first match can save traversing the rest
+func fansOut(path *PathExpr) bool {
+	for _, sg := range path.Segments {
+		if sg.Descendant {
+			return true
+		}
+		for _, sel := range sg.Selectors {
+			if sel.Kind != SelectorName && sel.Kind != SelectorIndex {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -18,32 +18,21 @@ type (
 	}
 
 	complianceCase struct {
-		Name            string   `json:"name"`
-		Selector        string   `json:"selector"`
-		Document        any      `json:"document"`
-		Result          []any    `json:"result"`
-		Results         [][]any  `json:"results"`
-		InvalidSelector bool     `json:"invalid_selector"`
-		Tags            []string `json:"tags"`
+		Name            string     `json:"name"`
+		Selector        string     `json:"selector"`
+		Document        any        `json:"document"`
+		Result          []any      `json:"result"`
+		Results         [][]any    `json:"results"`
+		ResultPaths     []string   `json:"result_paths"`
+		ResultsPaths    [][]string `json:"results_paths"`
+		InvalidSelector bool       `json:"invalid_selector"`
+		Tags            []string   `json:"tags"`
 	}
 )
 
 func TestComplianceSuite(t *testing.T) {
 	reg := jpath.NewRegistry()
-	path := filepath.Join(
-		"testdata", "jsonpath-compliance-test-suite", "cts.json",
-	)
-	if env := os.Getenv("JSONPATH_CTS_FILE"); env != "" {
-		path = env
-	}
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		t.Fatalf("compliance suite unavailable at %s: %v", path, err)
-	}
-	var suite complianceSuite
-	if !assert.NoError(t, json.Unmarshal(buf, &suite)) {
-		return
-	}
+	suite := loadComplianceSuite(t)
 	for _, tc := range suite.Tests {
 		t.Run(tc.Name, func(t *testing.T) {
 			t.Parallel()
@@ -71,3 +60,55 @@ func TestComplianceSuite(t *testing.T) {
 		})
 	}
 }
+
+func TestComplianceSuiteLocations(t *testing.T) {
+	reg := jpath.NewRegistry()
+	suite := loadComplianceSuite(t)
+	for _, tc := range suite.Tests {
+		if tc.InvalidSelector {
+			continue
+		}
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			got, err := reg.Locate(tc.Selector, tc.Document)
+			if !assert.NoError(t, err) {
+				return
+			}
+			values := got.Values()
+			paths := got.Paths()
+			if len(tc.ResultsPaths) > 0 {
+				for idx, expected := range tc.ResultsPaths {
+					if reflect.DeepEqual(expected, paths) &&
+						reflect.DeepEqual(tc.Results[idx], values) {
+						return
+					}
+				}
+				assert.Failf(t, "unexpected result", "%#v", paths)
+				return
+			}
+			assert.Equal(t, tc.Result, values)
+			if tc.ResultPaths != nil {
+				assert.Equal(t, tc.ResultPaths, paths)
+			}
+		})
+	}
+}
+
+func loadComplianceSuite(t *testing.T) *complianceSuite {
+	t.Helper()
+	path := filepath.Join(
+		"testdata", "jsonpath-compliance-test-suite", "cts.json",
+	)
+	if env := os.Getenv("JSONPATH_CTS_FILE"); env != "" {
+		path = env
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("compliance suite unavailable at %s: %v", path, err)
+	}
+	var suite complianceSuite
+	if err := json.Unmarshal(buf, &suite); err != nil {
+		t.Fatalf("invalid compliance suite %s: %v", path, err)
+	}
+	return &suite
+}
@@ -0,0 +1,223 @@
+package jpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// QueryError describes why a query could not be parsed, validated, or
+// compiled. It matches ErrInvalidPath and the error it wraps with
+// errors.Is, so the sentinel in Code can be tested either way
+type QueryError struct {
+	// Query is the text of the query that failed
+	Query string
+
+	// Offset is the byte offset of the error within Query
+	Offset int
+
+	// RuneOffset is the rune offset of the error within Query
+	RuneOffset int
+
+	// Length is the number of runes covered by the error. It is zero when
+	// the error is at the end of Query
+	Length int
+
+	// Code is the sentinel identifying the kind of error, such as
+	// ErrUnexpectedToken or ErrInvalidFuncArity
+	Code error
+
+	// Expected lists the tokens that would have been accepted at Offset,
+	// when the parser knows them. Tokens are either punctuation, such as
+	// "]", or a kind of token, such as "name" or "integer"
+	Expected []string
+
+	// Err is the error being reported, which wraps Code
+	Err error
+}
+
+// QueryErrors lists every error found in a query by ParseRecover, in the
+// order they occur
+type QueryErrors []*QueryError
+
+const (
+	tokenEnd      = "end of query"
+	tokenName     = "name"
+	tokenString   = "string"
+	tokenNumber   = "number"
+	tokenInteger  = "integer"
+	tokenFunction = "function"
+)
+
+var (
+	selectorTokens = []string{
+		tokenString, tokenInteger, ":", "*", "?",
+	}
+
+	operandTokens = []string{
+		"(", "!", "$", "@", tokenString, tokenNumber,
+		"true", "false", "null", tokenFunction,
+	}
+)
+
+var errorCodes = []error{
+	ErrExpectedRoot,
+	ErrUnexpectedToken,
+	ErrUnterminatedString,
+	ErrBadEscape,
+	ErrBadNumber,
+	ErrBadSlice,
+	ErrBadFunc,
+	ErrQueryTooLong,
+	ErrQueryTooDeep,
+	ErrLiteralMustBeCompared,
+	ErrCompRequiresSingularQuery,
+	ErrInvalidFuncArity,
+	ErrFuncResultMustBeCompared,
+	ErrFuncResultMustNotBeCompared,
+	ErrFuncRequiresSingularQuery,
+	ErrFuncRequiresQueryArgument,
+	ErrFuncArgumentType,
+	ErrUnknownFunc,
+	ErrArithmeticMustBeCompared,
+	ErrArithmeticOperand,
+	ErrBadPattern,
+}
+
+// Error implements the error interface
+func (e *QueryError) Error() string {
+	return fmt.Sprintf(
+		"%s at offset %d in %q: %s",
+		ErrInvalidPath, e.RuneOffset, e.Query, e.Err,
+	)
+}
+
+// Unwrap returns ErrInvalidPath and the error being reported
+func (e *QueryError) Unwrap() []error {
+	return []error{ErrInvalidPath, e.Err}
+}
+
+// Error implements the error interface, listing one error per line
+func (e QueryErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, qe := range e {
+		msgs[i] = qe.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors in the list
+func (e QueryErrors) Unwrap() []error {
+	res := make([]error, len(e))
+	for i, qe := range e {
+		res[i] = qe
+	}
+	return res
+}
+
+// Snippet renders the query with carets underlining the error
+func (e *QueryError) Snippet() string {
+	var b strings.Builder
+	for _, r := range e.Query {
+		if r == '\t' || r == '\n' || r == '\r' {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat(" ", e.RuneOffset))
+	b.WriteString(strings.Repeat("^", max(e.Length, 1)))
+	return b.String()
+}
+
+// MarshalJSON encodes the error as an object, with Code and Err rendered
+// as their messages
+func (e *QueryError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Message    string   `json:"message"`
+		Query      string   `json:"query"`
+		Offset     int      `json:"offset"`
+		RuneOffset int      `json:"runeOffset"`
+		Length     int      `json:"length"`
+		Code       string   `json:"code"`
+		Expected   []string `json:"expected,omitempty"`
+		Snippet    string   `json:"snippet"`
+	}{
+		Message:    e.Err.Error(),
+		Query:      e.Query,
+		Offset:     e.Offset,
+		RuneOffset: e.RuneOffset,
+		Length:     e.Length,
+		Code:       e.Code.Error(),
+		Expected:   e.Expected,
+		Snippet:    e.Snippet(),
+	})
+}
+
+// wrapPathError reports a parse error at rune offset pos of query. The
+// error covers the rune at pos, if there is one
+func wrapPathError(
+	query string, pos int, err error, expected ...string,
+) error {
+	length := 0
+	if pos < utf8.RuneCountInString(query) {
+		length = 1
+	}
+	sp := Span{Start: pos, End: pos + length}
+	return newQueryError(query, sp, err, expected)
+}
+
+// wrapQueryError reports a validation or compile error, covering the span
+// of the node that caused it when it is known
+func wrapQueryError(query string, err error) *QueryError {
+	var sp Span
+	var se *SpanError
+	if errors.As(err, &se) {
+		sp = se.Span
+	}
+	return newQueryError(query, sp, err, nil)
+}
+
+func newQueryError(
+	query string, sp Span, err error, expected []string,
+) *QueryError {
+	return &QueryError{
+		Query:      query,
+		Offset:     byteOffset(query, sp.Start),
+		RuneOffset: sp.Start,
+		Length:     sp.End - sp.Start,
+		Code:       errorCode(err),
+		Expected:   expected,
+		Err:        err,
+	}
+}
+
+// errorCode returns the sentinel that err wraps. For an error wrapping
+// none of them, such as one returned by a Validator, it returns the
+// innermost error in its chain
+func errorCode(err error) error {
+	for _, code := range errorCodes {
+		if errors.Is(err, code) {
+			return code
+		}
+	}
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+func byteOffset(query string, runes int) int {
+	for idx := range query {
+		if runes == 0 {
+			return idx
+		}
+		runes--
+	}
+	return len(query)
+}
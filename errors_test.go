@@ -0,0 +1,132 @@
+package jpath_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func queryError(t *testing.T, err error) *jpath.QueryError {
+	t.Helper()
+	var qe *jpath.QueryError
+	if !assert.ErrorAs(t, err, &qe) {
+		t.FailNow()
+	}
+	return qe
+}
+
+func TestQueryErrorParse(t *testing.T) {
+	_, err := jpath.Parse(`$.é[1 2]`)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+	assert.ErrorIs(t, err, jpath.ErrUnexpectedToken)
+
+	qe := queryError(t, err)
+	assert.Equal(t, `$.é[1 2]`, qe.Query)
+	assert.Equal(t, 6, qe.RuneOffset)
+	assert.Equal(t, 7, qe.Offset)
+	assert.Equal(t, 1, qe.Length)
+	assert.Equal(t, jpath.ErrUnexpectedToken, qe.Code)
+	assert.Equal(t, []string{"]", ","}, qe.Expected)
+	assert.Equal(t, "$.é[1 2]\n      ^", qe.Snippet())
+	assert.Equal(t,
+		`invalid JSONPath query at offset 6 in "$.é[1 2]": unexpected token`,
+		qe.Error(),
+	)
+}
+
+func TestQueryErrorExpected(t *testing.T) {
+	cases := map[string][]string{
+		``:       {"$"},
+		`$.a]`:   {"end of query"},
+		`$.`:     {"name", "*"},
+		`$..`:    {"name", "*", "["},
+		`$[`:     {"string", "integer", ":", "*", "?"},
+		`$[1:x]`: {"integer"},
+		`$[?`: {
+			"(", "!", "$", "@", "string", "number",
+			"true", "false", "null", "function",
+		},
+		`$[?(@.a]`:     {")"},
+		`$[?foo]`:      {"("},
+		`$[?foo(@ @)]`: {",", ")"},
+		`$[?@ == -]`:   {"number"},
+		`$["a`:         nil,
+	}
+	for query, want := range cases {
+		_, err := jpath.Parse(query)
+		qe := queryError(t, err)
+		assert.Equal(t, want, qe.Expected, query)
+	}
+}
+
+func TestQueryErrorAtEnd(t *testing.T) {
+	_, err := jpath.Parse(`$.a[`)
+	qe := queryError(t, err)
+	assert.Equal(t, 4, qe.RuneOffset)
+	assert.Equal(t, 4, qe.Offset)
+	assert.Equal(t, 0, qe.Length)
+	assert.Equal(t, "$.a[\n    ^", qe.Snippet())
+}
+
+func TestQueryErrorValidation(t *testing.T) {
+	query := "$[?@.a &&\tlength(@.*) > 1]"
+	_, err := jpath.Query(query, nil)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+	assert.ErrorIs(t, err, jpath.ErrFuncRequiresSingularQuery)
+
+	qe := queryError(t, err)
+	assert.Equal(t, jpath.ErrFuncRequiresSingularQuery, qe.Code)
+	assert.Equal(t, 17, qe.RuneOffset)
+	assert.Equal(t, 3, qe.Length)
+	assert.Nil(t, qe.Expected)
+	assert.Equal(t,
+		"$[?@.a && length(@.*) > 1]\n                 ^^^", qe.Snippet(),
+	)
+
+	var se *jpath.SpanError
+	assert.ErrorAs(t, err, &se)
+}
+
+func TestQueryErrorCustomCode(t *testing.T) {
+	bad := errors.New("not today")
+	reg := jpath.NewRegistry()
+	reg.MustRegisterDefinition("never", &jpath.FunctionDefinition{
+		Validate: func(
+			_ []jpath.FilterExpr, _ jpath.FunctionUse, _ bool,
+		) error {
+			return bad
+		},
+		Eval: func(args []*jpath.Value) *jpath.Value {
+			return jpath.ScalarValue(true)
+		},
+	})
+	_, err := reg.Query(`$[?never()]`, nil)
+	assert.ErrorIs(t, err, bad)
+	qe := queryError(t, err)
+	assert.Equal(t, bad, qe.Code)
+	assert.Equal(t, 3, qe.RuneOffset)
+	assert.Equal(t, 7, qe.Length)
+}
+
+func TestQueryErrorJSON(t *testing.T) {
+	_, err := jpath.Parse(`$[?@ == 'a]`)
+	buf, jerr := json.Marshal(queryError(t, err))
+	if !assert.NoError(t, jerr) {
+		return
+	}
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(buf, &got))
+	assert.Equal(t, map[string]any{
+		"message":    "unterminated string",
+		"query":      `$[?@ == 'a]`,
+		"offset":     11.0,
+		"runeOffset": 11.0,
+		"length":     0.0,
+		"code":       "unterminated string",
+		"snippet":    "$[?@ == 'a]\n           ^",
+	}, got)
+}
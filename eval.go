@@ -1,8 +1,8 @@
 package jpath
 
 import (
+	"encoding/json"
 	"reflect"
-	"strings"
 )
 
 type (
@@ -11,8 +11,13 @@ type (
 	FilterCtx struct {
 		Root    any
 		Current any
+		env     *evalEnv
+		hoisted map[*hoistSlot]*Value
 	}
 
+	// hoistSlot identifies one hoisted subquery within a FilterCtx
+	hoistSlot struct{ _ byte }
+
 	matchFunc func(left, right any) bool
 )
 
@@ -58,14 +63,14 @@ func compareValuesEq(left, right *Value) bool {
 	if left.Count() == 0 || right.Count() == 0 {
 		return compareEmptyEq(left, right)
 	}
-	return matchAny(left, right, reflect.DeepEqual)
+	return matchAny(left, right, jsonEqual)
 }
 
 func compareValuesNe(left, right *Value) bool {
 	if left.Count() == 0 || right.Count() == 0 {
 		return compareEmptyNe(left, right)
 	}
-	return matchAny(left, right, notDeepEqual)
+	return matchAny(left, right, notJSONEqual)
 }
 
 func compareValuesLt(left, right *Value) bool {
@@ -154,8 +159,45 @@ func greaterThan(left, right any) (bool, bool) {
 	return false, false
 }
 
-func notDeepEqual(left, right any) bool {
-	return !reflect.DeepEqual(left, right)
+// jsonEqual reports whether two values are equal as JSON values. Numbers
+// are equal by value whatever their Go type, arrays by their elements in
+// order, and objects by their members regardless of order
+func jsonEqual(left, right any) bool {
+	if ln, ok := asNumber(left); ok {
+		rn, ok := asNumber(right)
+		return ok && ln == rn
+	}
+	switch l := left.(type) {
+	case []any:
+		r, ok := right.([]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for i, v := range l {
+			if !jsonEqual(v, r[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		r, ok := right.(map[string]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for k, v := range l {
+			rv, ok := r[k]
+			if !ok || !jsonEqual(v, rv) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(left, right)
+	}
+}
+
+func notJSONEqual(left, right any) bool {
+	return !jsonEqual(left, right)
 }
 
 func lessThanMatch(left, right any) bool {
@@ -164,7 +206,7 @@ func lessThanMatch(left, right any) bool {
 }
 
 func lessEqualMatch(left, right any) bool {
-	if reflect.DeepEqual(left, right) {
+	if jsonEqual(left, right) {
 		return true
 	}
 	return lessThanMatch(left, right)
@@ -176,7 +218,7 @@ func greaterThanMatch(left, right any) bool {
 }
 
 func greaterEqualMatch(left, right any) bool {
-	if reflect.DeepEqual(left, right) {
+	if jsonEqual(left, right) {
 		return true
 	}
 	return greaterThanMatch(left, right)
@@ -205,32 +247,9 @@ func asNumber(value any) (float64, bool) {
 	if n, ok := value.(int); ok {
 		return float64(n), true
 	}
-	return 0, false
-}
-
-func normalizeDotPattern(pattern string) string {
-	var b strings.Builder
-	escaped := false
-	inClass := false
-	for _, r := range pattern {
-		switch {
-		case escaped:
-			b.WriteRune(r)
-			escaped = false
-		case r == '\\':
-			b.WriteRune(r)
-			escaped = true
-		case r == '[':
-			b.WriteRune(r)
-			inClass = true
-		case r == ']':
-			b.WriteRune(r)
-			inClass = false
-		case r == '.' && !inClass:
-			b.WriteString("[^\\r\\n]")
-		default:
-			b.WriteRune(r)
-		}
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
 	}
-	return b.String()
+	return 0, false
 }
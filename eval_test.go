@@ -1,6 +1,7 @@
 package jpath_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -102,28 +103,49 @@ func TestFilterCompareOperators(t *testing.T) {
 	assert.Equal(t, []any{float64(1), float64(3)}, got)
 }
 
+func TestFilterCompareJSONNumbers(t *testing.T) {
+	doc := []any{
+		json.Number("12345678901234567890"), json.Number("1.50"), float64(3),
+	}
+	reg := jpath.NewRegistry()
+
+	got, err := reg.Query("$[?@ > 2]", doc)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []any{doc[0], doc[2]}, got)
+	}
+
+	got, err = reg.Query("$[?@ == 1.5 || @ == 3.0]", doc)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []any{doc[1], doc[2]}, got)
+	}
+}
+
 func TestFilterMatchSearchEdgeCases(t *testing.T) {
 	doc := []any{
 		map[string]any{
 			"left":  []any{"aa", "bb"},
 			"right": []any{"xx", "yy"},
+			"bad":   "[",
 		},
 	}
 	reg := jpath.NewRegistry()
 
-	got, err := reg.Query("$[?match(@.left[*], 'aa')]", doc)
-	if !assert.NoError(t, err) {
-		return
-	}
-	assert.Empty(t, got)
+	_, err := reg.Query("$[?match(@.left[*], 'aa')]", doc)
+	assert.ErrorIs(t, err, jpath.ErrFuncRequiresSingularQuery)
+
+	_, err = reg.Query("$[?match('aa', @.right[*])]", doc)
+	assert.ErrorIs(t, err, jpath.ErrFuncRequiresSingularQuery)
 
-	got, err = reg.Query("$[?match('aa', @.right[*])]", doc)
+	got, err := reg.Query("$[?match(@.left[9], 'aa')]", doc)
 	if !assert.NoError(t, err) {
 		return
 	}
 	assert.Empty(t, got)
 
-	got, err = reg.Query("$[?search('abc', '[')]", doc)
+	_, err = reg.Query("$[?search('abc', '[')]", doc)
+	assert.ErrorIs(t, err, jpath.ErrBadPattern)
+
+	got, err = reg.Query("$[?search('abc', @.bad)]", doc)
 	if !assert.NoError(t, err) {
 		return
 	}
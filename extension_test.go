@@ -0,0 +1,214 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+const (
+	arith     = jpath.ExtensionArithmetic
+	composite = jpath.ExtensionCompositeLiterals
+	member    = jpath.ExtensionMembership
+	members   = composite | member
+	regex     = jpath.ExtensionRegexMatch
+)
+
+func extensionRegistry(ext jpath.Extension) *jpath.Registry {
+	return jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		Extensions: ext,
+	})
+}
+
+func TestExtensionErrors(t *testing.T) {
+	cases := []struct {
+		ext   jpath.Extension
+		query string
+		want  error
+	}{
+		{arith, `$[?@.a + 1]`, jpath.ErrArithmeticMustBeCompared},
+		{arith, `$[?-@.a]`, jpath.ErrArithmeticMustBeCompared},
+		{arith, `$[?@.b && @.a * 2]`, jpath.ErrArithmeticMustBeCompared},
+		{arith, `$[?'a' + 1 == 2]`, jpath.ErrArithmeticOperand},
+		{arith, `$[?@.a + true == 2]`, jpath.ErrArithmeticOperand},
+		{arith, `$[?-null == 2]`, jpath.ErrArithmeticOperand},
+		{arith, `$[?@.a + (@.b == 1) == 2]`, jpath.ErrArithmeticOperand},
+		{arith, `$[?@.a * !@.b == 2]`, jpath.ErrArithmeticOperand},
+		{arith, `$[?@.* + 1 == 2]`, jpath.ErrCompRequiresSingularQuery},
+		{arith, `$[?-@..a == 2]`, jpath.ErrCompRequiresSingularQuery},
+		{arith, `$[?count(@.a + 1) == 2]`, jpath.ErrFuncRequiresQueryArgument},
+		{
+			arith, `$[?match(@.a, 'x') + 1 > 0]`,
+			jpath.ErrFuncResultMustNotBeCompared,
+		},
+		{members, `$[?@.a in [@.b]]`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.a in [1 2]]`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.a in [1,`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.a == {1: 2}]`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.a == {'k' 2}]`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.a == {'k': 2]`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.a == ['x]]`, jpath.ErrUnterminatedString},
+		{members, `$[?['a']]`, jpath.ErrLiteralMustBeCompared},
+		{members, `$[?@.a in`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.* in ['a']]`, jpath.ErrCompRequiresSingularQuery},
+		{members, `$[?@.a anyof ['a'] in]`, jpath.ErrUnexpectedToken},
+	}
+	for _, c := range cases {
+		_, err := extensionRegistry(c.ext).Query(c.query, nil)
+		assert.ErrorIs(t, err, c.want, c.query)
+		assert.Equal(t, c.want, queryError(t, err).Code, c.query)
+	}
+}
+
+func TestExtensionFormat(t *testing.T) {
+	cases := []struct {
+		ext   jpath.Extension
+		query string
+		want  string // empty when the query is invalid
+	}{
+		{arith, `$[?@.a*2==4]`, `$[?@.a * 2 == 4]`},
+		{arith, `$[?(@.a * 2) + 1 == 4]`, `$[?@.a * 2 + 1 == 4]`},
+		{arith, `$[?@.a * (2 + 1) == 4]`, `$[?@.a * (2 + 1) == 4]`},
+		{arith, `$[?@.a - (@.b - @.c) == 4]`, `$[?@.a - (@.b - @.c) == 4]`},
+		{arith, `$[?-(@.a + 1) == 4]`, `$[?-(@.a + 1) == 4]`},
+		{arith, `$[?- 1 == @.a]`, `$[?-1 == @.a]`},
+		{arith, `$[?--@.a == 1]`, `$[?--@.a == 1]`},
+		{members, `$[?@.a in["x","y"]]`, `$[?@.a in ['x', 'y']]`},
+		{members, `$[?@.a nin [ ]]`, `$[?@.a nin []]`},
+		{members, `$[?@.a==={"b":[1,{}],"a":null}`, ``},
+		{
+			members, `$[?@.a=={"b":[1,{}],"a":null}]`,
+			`$[?@.a == {'a': null, 'b': [1, {}]}]`,
+		},
+		{members, `$[?@.a subsetof@.b]`, `$[?@.a subsetof @.b]`},
+		{members, `$[?(@.a anyof [1]) || @.b]`, `$[?@.a anyof [1] || @.b]`},
+		{regex, `$[?@.a=~"x+"]`, `$[?@.a =~ 'x+']`},
+		{regex, `$[?!(@.a =~ 'x') && @.b]`, `$[?!(@.a =~ 'x') && @.b]`},
+	}
+	for _, c := range cases {
+		reg := extensionRegistry(c.ext)
+		ast, err := reg.Parse(c.query)
+		if c.want == "" {
+			assert.Error(t, err, c.query)
+			continue
+		}
+		if !assert.NoError(t, err, c.query) {
+			continue
+		}
+		assert.Equal(t, c.want, ast.String(), c.query)
+		again, err := reg.Parse(c.want)
+		if assert.NoError(t, err, c.query) {
+			assert.Equal(t, clearSpans(ast), clearSpans(again), c.query)
+		}
+	}
+}
+
+func TestExtensionOptimize(t *testing.T) {
+	cases := []struct {
+		ext   jpath.Extension
+		query string
+		want  string
+	}{
+		{arith, `$[?@.a == 2 * 3 + 1]`, `$[?@.a == 7]`},
+		{arith, `$[?@.a == -(2 * 3)]`, `$[?@.a == -6]`},
+		{arith, `$[?1 + 1 == 2]`, `$[*]`},
+		{arith, `$[?@.a == 1 / 0]`, `$[?@.a == 1 / 0]`},
+		{arith, `$[?@.a + 1 * 2 == 3]`, `$[?@.a + 2 == 3]`},
+		{arith, `$[?@.a * 2 == @.b - 1]`, `$[?@.a * 2 == @.b - 1]`},
+		{members, `$[?'a' in ['a', 'b']]`, `$[*]`},
+		{members, `$[?'a' nin ['a', 'b']]`, `$[0:0]`},
+		{members, `$[?[1] subsetof [1.0, 2]]`, `$[*]`},
+		{members, `$[?@.a in ['a'] && [] anyof []]`, `$[0:0]`},
+		{members, `$[?@.a in ['a']]`, `$[?@.a in ['a']]`},
+	}
+	for _, c := range cases {
+		reg := extensionRegistry(c.ext)
+		ast, err := reg.Parse(c.query)
+		if !assert.NoError(t, err, c.query) {
+			continue
+		}
+		opt := jpath.Optimize(ast)
+		assert.Equal(t, c.want, opt.String(), c.query)
+		_, err = reg.Compile(opt)
+		assert.NoError(t, err, c.query)
+	}
+}
+
+func TestExtensionSpans(t *testing.T) {
+	cases := []struct {
+		ext   jpath.Extension
+		query string
+		spans func(jpath.FilterExpr) []jpath.Span
+		want  []string
+	}{
+		{
+			arith, `$[?-@.a * 2 + 1 == 4]`,
+			func(f jpath.FilterExpr) []jpath.Span {
+				add := f.(*jpath.BinaryExpr).Left.(*jpath.ArithmeticExpr)
+				mul := add.Left.(*jpath.ArithmeticExpr)
+				neg := mul.Left.(*jpath.NegateExpr)
+				return []jpath.Span{add.Span, mul.Span, neg.Span}
+			},
+			[]string{"-@.a * 2 + 1", "-@.a * 2", "-@.a"},
+		},
+		{
+			members, `$[?@.a in [1, {'k': [2]}]]`,
+			func(f jpath.FilterExpr) []jpath.Span {
+				in := f.(*jpath.BinaryExpr)
+				return []jpath.Span{in.Span, in.Right.(*jpath.LiteralExpr).Span}
+			},
+			[]string{"@.a in [1, {'k': [2]}]", "[1, {'k': [2]}]"},
+		},
+	}
+	for _, c := range cases {
+		ast, err := extensionRegistry(c.ext).Parse(c.query)
+		if !assert.NoError(t, err, c.query) {
+			continue
+		}
+		var got []string
+		for _, sp := range c.spans(ast.Segments[0].Selectors[0].Filter) {
+			got = append(got, spanText(c.query, sp))
+		}
+		assert.Equal(t, c.want, got, c.query)
+	}
+}
+
+func TestExtensionRequired(t *testing.T) {
+	// Each query parses with the extensions it names, and fails with the
+	// error given when strict or when any one of them is disabled
+	cases := []struct {
+		ext   jpath.Extension
+		query string
+		want  error
+	}{
+		{arith, `$[?@.a * 2 == 4]`, jpath.ErrUnexpectedToken},
+		{arith, `$[?-@.a == 4]`, jpath.ErrBadNumber},
+		{composite, `$[?@.a == ['x']]`, jpath.ErrUnexpectedToken},
+		{composite, `$[?@.a == {'k': 1}]`, jpath.ErrUnexpectedToken},
+		{member, `$[?@.a in @.b]`, jpath.ErrUnexpectedToken},
+		{members, `$[?@.a in ['x']]`, jpath.ErrUnexpectedToken},
+		{regex, `$[?@.a =~ 'x']`, jpath.ErrUnexpectedToken},
+	}
+	for _, c := range cases {
+		_, err := extensionRegistry(c.ext).Parse(c.query)
+		assert.NoError(t, err, c.query)
+
+		regs := []*jpath.Registry{
+			jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+				Strict:     true,
+				Extensions: c.ext,
+			}),
+		}
+		for ext := jpath.Extension(1); ext <= c.ext; ext <<= 1 {
+			if c.ext.Has(ext) {
+				regs = append(regs, extensionRegistry(c.ext&^ext))
+			}
+		}
+		for _, reg := range regs {
+			_, err := reg.Parse(c.query)
+			assert.ErrorIs(t, err, c.want, c.query)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package jpath
 
+import "math"
+
 // Literal builds a filter function that returns a scalar literal value
 func Literal(value any) FilterFunc {
 	return func(_ *FilterCtx) *Value {
@@ -92,6 +94,111 @@ func Ge(left, right FilterFunc) FilterFunc {
 	}
 }
 
+// In builds a filter function testing whether a single value is an element
+// of an array, using JSON equality
+func In(left, right FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		return ScalarValue(valueIn(left(ctx), right(ctx)))
+	}
+}
+
+// Nin builds a filter function testing whether a single value is not an
+// element of an array. Like In, it is false when the right isn't an array
+func Nin(left, right FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		return ScalarValue(valueNin(left(ctx), right(ctx)))
+	}
+}
+
+// SubsetOf builds a filter function testing whether every element of one
+// array is an element of another
+func SubsetOf(left, right FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		return ScalarValue(valueSubsetOf(left(ctx), right(ctx)))
+	}
+}
+
+// AnyOf builds a filter function testing whether any element of one array
+// is an element of another
+func AnyOf(left, right FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		return ScalarValue(valueAnyOf(left(ctx), right(ctx)))
+	}
+}
+
+// Match builds a filter function testing whether a single string matches
+// an I-Regexp pattern in its entirety, as the =~ operator does
+func Match(left, right FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		res := evalFullMatch([]*Value{left(ctx), right(ctx)})
+		return ScalarValue(toBool(res))
+	}
+}
+
+// Add builds a filter function that adds two numbers
+func Add(left, right FilterFunc) FilterFunc {
+	return arithmetic(left, right, func(l, r float64) float64 {
+		return l + r
+	})
+}
+
+// Sub builds a filter function that subtracts one number from another
+func Sub(left, right FilterFunc) FilterFunc {
+	return arithmetic(left, right, func(l, r float64) float64 {
+		return l - r
+	})
+}
+
+// Mul builds a filter function that multiplies two numbers
+func Mul(left, right FilterFunc) FilterFunc {
+	return arithmetic(left, right, func(l, r float64) float64 {
+		return l * r
+	})
+}
+
+// Div builds a filter function that divides one number by another
+func Div(left, right FilterFunc) FilterFunc {
+	return arithmetic(left, right, func(l, r float64) float64 {
+		return l / r
+	})
+}
+
+// Mod builds a filter function that returns the remainder of dividing one
+// number by another, with the sign of the dividend
+func Mod(left, right FilterFunc) FilterFunc {
+	return arithmetic(left, right, math.Mod)
+}
+
+// Neg builds a filter function that negates a number
+func Neg(expr FilterFunc) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		n, ok := arithmeticValue(expr(ctx))
+		if !ok {
+			return ScalarValue(nothing)
+		}
+		return ScalarValue(-n)
+	}
+}
+
+// arithmetic builds a filter function applying op to two numbers. The
+// result is Nothing when either operand is not exactly one number, or
+// when the result isn't finite
+func arithmetic(
+	left, right FilterFunc, op func(l, r float64) float64,
+) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		l, ok := arithmeticValue(left(ctx))
+		if !ok {
+			return ScalarValue(nothing)
+		}
+		r, ok := arithmeticValue(right(ctx))
+		if !ok {
+			return ScalarValue(nothing)
+		}
+		return arithmeticResult(op(l, r))
+	}
+}
+
 // Call builds a filter function from a function evaluator and arguments
 func Call(evaluator Evaluator, args ...FilterFunc) FilterFunc {
 	return func(ctx *FilterCtx) *Value {
@@ -0,0 +1,292 @@
+package jpath
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	precOr = iota + 1
+	precAnd
+	precCompare
+	precAdditive
+	precMultiplicative
+	precUnary
+	precPrimary
+)
+
+// String renders the path as a canonical JSONPath query. Parsing the result
+// yields a syntax tree equal to this one
+func (p *PathExpr) String() string {
+	var b strings.Builder
+	b.WriteByte('$')
+	writeSegments(&b, p.Segments)
+	return b.String()
+}
+
+// String renders the segment in canonical query syntax
+func (s *SegmentExpr) String() string {
+	var b strings.Builder
+	writeSegment(&b, s)
+	return b.String()
+}
+
+// String renders the selector in canonical bracket syntax, without the
+// enclosing brackets
+func (s *SelectorExpr) String() string {
+	var b strings.Builder
+	writeSelector(&b, s)
+	return b.String()
+}
+
+// String renders the slice bounds in canonical query syntax
+func (s *SliceExpr) String() string {
+	var b strings.Builder
+	writeSlice(&b, s)
+	return b.String()
+}
+
+// String renders the literal in canonical query syntax
+func (l *LiteralExpr) String() string {
+	return formatFilter(l)
+}
+
+// String renders the path value in canonical query syntax
+func (p *PathValueExpr) String() string {
+	return formatFilter(p)
+}
+
+// String renders the unary expression in canonical query syntax
+func (u *UnaryExpr) String() string {
+	return formatFilter(u)
+}
+
+// String renders the binary expression in canonical query syntax
+func (b *BinaryExpr) String() string {
+	return formatFilter(b)
+}
+
+// String renders the arithmetic expression in canonical query syntax
+func (a *ArithmeticExpr) String() string {
+	return formatFilter(a)
+}
+
+// String renders the negation in canonical query syntax
+func (n *NegateExpr) String() string {
+	return formatFilter(n)
+}
+
+// String renders the function call in canonical query syntax
+func (f *FuncExpr) String() string {
+	return formatFilter(f)
+}
+
+func formatFilter(ex FilterExpr) string {
+	var b strings.Builder
+	writeFilter(&b, ex)
+	return b.String()
+}
+
+func writeSegments(b *strings.Builder, segments []*SegmentExpr) {
+	for _, sg := range segments {
+		writeSegment(b, sg)
+	}
+}
+
+func writeSegment(b *strings.Builder, s *SegmentExpr) {
+	if s.Descendant {
+		b.WriteString("..")
+	}
+	if len(s.Selectors) == 1 {
+		sel := s.Selectors[0]
+		switch {
+		case sel.Kind == SelectorName && isIdentifier(sel.Name):
+			if !s.Descendant {
+				b.WriteByte('.')
+			}
+			b.WriteString(sel.Name)
+			return
+		case sel.Kind == SelectorWildcard && s.Descendant:
+			b.WriteByte('*')
+			return
+		}
+	}
+	b.WriteByte('[')
+	for idx, sel := range s.Selectors {
+		if idx > 0 {
+			b.WriteString(", ")
+		}
+		writeSelector(b, sel)
+	}
+	b.WriteByte(']')
+}
+
+func writeSelector(b *strings.Builder, s *SelectorExpr) {
+	switch s.Kind {
+	case SelectorName:
+		writeQuoted(b, s.Name)
+	case SelectorIndex:
+		b.WriteString(strconv.Itoa(s.Index))
+	case SelectorWildcard:
+		b.WriteByte('*')
+	case SelectorSlice:
+		writeSlice(b, s.Slice)
+	case SelectorFilter:
+		b.WriteByte('?')
+		writeFilter(b, s.Filter)
+	}
+}
+
+func writeSlice(b *strings.Builder, s *SliceExpr) {
+	if s.HasStart {
+		b.WriteString(strconv.Itoa(s.Start))
+	}
+	b.WriteByte(':')
+	if s.HasEnd {
+		b.WriteString(strconv.Itoa(s.End))
+	}
+	if s.Step != 1 {
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(s.Step))
+	}
+}
+
+func writeFilter(b *strings.Builder, ex FilterExpr) {
+	switch v := ex.(type) {
+	case *LiteralExpr:
+		writeLiteral(b, v.Value)
+
+	case *PathValueExpr:
+		if v.Absolute {
+			b.WriteByte('$')
+		} else {
+			b.WriteByte('@')
+		}
+		writeSegments(b, v.Path.Segments)
+
+	case *UnaryExpr:
+		b.WriteString(v.Op)
+		writeOperand(b, v.Expr, precUnary)
+
+	case *BinaryExpr:
+		prec := binaryPrecedence(v.Op)
+		writeOperand(b, v.Left, prec)
+		b.WriteByte(' ')
+		b.WriteString(v.Op)
+		b.WriteByte(' ')
+		writeOperand(b, v.Right, prec+1)
+
+	case *ArithmeticExpr:
+		prec := arithmeticPrecedence(v.Op)
+		writeOperand(b, v.Left, prec)
+		b.WriteByte(' ')
+		b.WriteString(v.Op)
+		b.WriteByte(' ')
+		writeOperand(b, v.Right, prec+1)
+
+	case *NegateExpr:
+		b.WriteByte('-')
+		writeOperand(b, v.Expr, precUnary)
+
+	case *FuncExpr:
+		b.WriteString(v.Name)
+		b.WriteByte('(')
+		for idx, arg := range v.Args {
+			if idx > 0 {
+				b.WriteString(", ")
+			}
+			writeFilter(b, arg)
+		}
+		b.WriteByte(')')
+
+	default:
+		fmt.Fprintf(b, "<%T>", ex)
+	}
+}
+
+func writeOperand(b *strings.Builder, ex FilterExpr, minPrec int) {
+	if filterPrecedence(ex) >= minPrec {
+		writeFilter(b, ex)
+		return
+	}
+	b.WriteByte('(')
+	writeFilter(b, ex)
+	b.WriteByte(')')
+}
+
+func writeLiteral(b *strings.Builder, value any) {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case string:
+		writeQuoted(b, v)
+	case float64:
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	case []any:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeLiteral(b, e)
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		b.WriteByte('{')
+		for i, k := range slices.Sorted(maps.Keys(v)) {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeQuoted(b, k)
+			b.WriteString(": ")
+			writeLiteral(b, v[k])
+		}
+		b.WriteByte('}')
+	default:
+		fmt.Fprint(b, v)
+	}
+}
+
+func writeQuoted(b *strings.Builder, s string) {
+	b.WriteByte('\'')
+	writeNormalizedName(b, s)
+	b.WriteByte('\'')
+}
+
+func filterPrecedence(ex FilterExpr) int {
+	switch v := ex.(type) {
+	case *BinaryExpr:
+		return binaryPrecedence(v.Op)
+	case *ArithmeticExpr:
+		return arithmeticPrecedence(v.Op)
+	case *UnaryExpr, *NegateExpr:
+		return precUnary
+	default:
+		return precPrimary
+	}
+}
+
+func binaryPrecedence(op string) int {
+	switch op {
+	case "||":
+		return precOr
+	case "&&":
+		return precAnd
+	default:
+		return precCompare
+	}
+}
+
+func arithmeticPrecedence(op string) int {
+	if op == "+" || op == "-" {
+		return precAdditive
+	}
+	return precMultiplicative
+}
@@ -0,0 +1,91 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestFormatCanonical(t *testing.T) {
+	cases := map[string]string{
+		`$`:                           `$`,
+		`$.store.book[*]`:             `$.store.book[*]`,
+		`$["a b"]['it\'s']`:           `$['a b']['it\'s']`,
+		`$..*`:                        `$..*`,
+		`$..name`:                     `$..name`,
+		`$..[0,'x']`:                  `$..[0, 'x']`,
+		`$[1:3]`:                      `$[1:3]`,
+		`$[::-1]`:                     `$[::-1]`,
+		`$[:]`:                        `$[:]`,
+		`$[1::2]`:                     `$[1::2]`,
+		`$["\u000a\t"]`:               `$['\n\t']`,
+		`$[?@.a==1]`:                  `$[?@.a == 1]`,
+		`$[?(@.a || @.b) && @.c]`:     `$[?(@.a || @.b) && @.c]`,
+		`$[?@.a || (@.b && @.c)]`:     `$[?@.a || @.b && @.c]`,
+		`$[?!(@.a == "x")]`:           `$[?!(@.a == 'x')]`,
+		`$[?!!@.a]`:                   `$[?!!@.a]`,
+		`$[?length(@.x)>=2.5e3]`:      `$[?length(@.x) >= 2500]`,
+		`$[?match(@, 'a.*') == null]`: `$[?match(@, 'a.*') == null]`,
+		`$[?$.x == true]`:             `$[?$.x == true]`,
+		`@.name == "x"`:               `$[?@.name == 'x']`,
+	}
+	for query, want := range cases {
+		ast, err := jpath.Parse(query)
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+		assert.Equal(t, want, ast.String(), query)
+	}
+}
+
+func TestFormatRightAssociative(t *testing.T) {
+	ast := &jpath.PathExpr{
+		Segments: []*jpath.SegmentExpr{{
+			Selectors: []*jpath.SelectorExpr{{
+				Kind: jpath.SelectorFilter,
+				Filter: &jpath.BinaryExpr{
+					Op:   "||",
+					Left: &jpath.PathValueExpr{Path: &jpath.PathExpr{}},
+					Right: &jpath.BinaryExpr{
+						Op: "||",
+						Left: &jpath.PathValueExpr{
+							Absolute: true,
+							Path:     &jpath.PathExpr{},
+						},
+						Right: &jpath.LiteralExpr{Value: 3},
+					},
+				},
+			}},
+		}},
+	}
+	assert.Equal(t, "$[?@ || ($ || 3)]", ast.String())
+
+	sel := ast.Segments[0].Selectors[0]
+	assert.Equal(t, "?@ || ($ || 3)", sel.String())
+	assert.Equal(t, "[?@ || ($ || 3)]", ast.Segments[0].String())
+	right := sel.Filter.(*jpath.BinaryExpr).Right.(*jpath.BinaryExpr)
+	assert.Equal(t, "$ || 3", right.String())
+	assert.Equal(t, "3", right.Right.(*jpath.LiteralExpr).String())
+}
+
+func TestFormatRoundTripCompliance(t *testing.T) {
+	suite := loadComplianceSuite(t)
+	for _, tc := range suite.Tests {
+		if tc.InvalidSelector {
+			continue
+		}
+		ast, err := jpath.Parse(tc.Selector)
+		if !assert.NoError(t, err, tc.Selector) {
+			continue
+		}
+		text := ast.String()
+		again, err := jpath.Parse(text)
+		if !assert.NoError(t, err, text) {
+			continue
+		}
+		assert.Equal(t, clearSpans(ast), clearSpans(again), tc.Selector)
+		assert.Equal(t, text, again.String(), tc.Selector)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/kode4food/lru v0.0.0-20260217084209-144fda8850c9
 	github.com/stretchr/testify v1.11.1
 	golang.org/x/tools v0.42.0
+	gopkg.in/yaml.v3 v3.0.1
 	honnef.co/go/tools v0.7.0
 )
 
@@ -18,5 +19,4 @@ require (
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.41.0 // indirect
 	golang.org/x/telemetry v0.0.0-20260213145524-e0ab670178e1 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
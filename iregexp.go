@@ -0,0 +1,403 @@
+package jpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// iregexp translates an I-Regexp (RFC 9485) pattern into the RE2 syntax
+// that matches the same strings, rejecting anything outside the subset
+type iregexp struct {
+	src []rune
+	pos int
+	out strings.Builder
+}
+
+// assignedClass matches every code point outside the Cn category. Go has
+// no table for Cn, but its C category includes the unassigned code points
+const assignedClass = `\P{C}\p{Cc}\p{Cf}\p{Co}\p{Cs}`
+
+// iregexpCategories are the character properties I-Regexp supports
+var iregexpCategories = map[string]bool{
+	"L": true, "Ll": true, "Lm": true, "Lo": true, "Lt": true, "Lu": true,
+	"M": true, "Mc": true, "Me": true, "Mn": true,
+	"N": true, "Nd": true, "Nl": true, "No": true,
+	"P": true, "Pc": true, "Pd": true, "Pe": true, "Pf": true, "Pi": true,
+	"Po": true, "Ps": true,
+	"Z": true, "Zl": true, "Zp": true, "Zs": true,
+	"S": true, "Sc": true, "Sk": true, "Sm": true, "So": true,
+	"C": true, "Cc": true, "Cf": true, "Cn": true, "Co": true,
+}
+
+// unassignedRanges is a character class body listing the code points in
+// the Cn category, which are those of Go's C category in no other table
+var unassignedRanges = sync.OnceValue(func() string {
+	var b strings.Builder
+	start, end := rune(-1), rune(-1)
+	add := func(r rune) {
+		if unicode.In(r, unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs) {
+			return
+		}
+		if r != end+1 && start >= 0 {
+			fmt.Fprintf(&b, `\x{%x}-\x{%x}`, start, end)
+			start = -1
+		}
+		if start < 0 {
+			start = r
+		}
+		end = r
+	}
+	for _, rg := range unicode.C.R16 {
+		for r := rune(rg.Lo); r <= rune(rg.Hi); r += rune(rg.Stride) {
+			add(r)
+		}
+	}
+	for _, rg := range unicode.C.R32 {
+		for r := rune(rg.Lo); r <= rune(rg.Hi); r += rune(rg.Stride) {
+			add(r)
+		}
+	}
+	fmt.Fprintf(&b, `\x{%x}-\x{%x}`, start, end)
+	return b.String()
+})
+
+// translateIRegexp translates an I-Regexp pattern into RE2 syntax. The
+// result is unanchored, as search() uses it
+func translateIRegexp(pattern string) (string, error) {
+	t := &iregexp{src: []rune(pattern)}
+	if err := t.alternation(); err != nil {
+		return "", err
+	}
+	if !t.eof() {
+		return "", t.fail("unmatched )")
+	}
+	return t.out.String(), nil
+}
+
+func (t *iregexp) alternation() error {
+	for {
+		if err := t.branch(); err != nil {
+			return err
+		}
+		if !t.consume('|') {
+			return nil
+		}
+		t.out.WriteByte('|')
+	}
+}
+
+func (t *iregexp) branch() error {
+	for !t.eof() {
+		if r := t.peek(); r == '|' || r == ')' {
+			return nil
+		}
+		if err := t.atom(); err != nil {
+			return err
+		}
+		if err := t.quantifier(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *iregexp) atom() error {
+	switch r := t.peek(); r {
+	case '(':
+		t.pos++
+		t.out.WriteString("(?:")
+		if err := t.alternation(); err != nil {
+			return err
+		}
+		if !t.consume(')') {
+			return t.fail("missing )")
+		}
+		t.out.WriteByte(')')
+	case '.':
+		t.pos++
+		t.out.WriteString(`[^\n\r]`)
+	case '^', '$':
+		// an anchor, as RFC 9485 translates it for PCRE and ECMAScript and
+		// as other RFC 9535 implementations treat it
+		t.pos++
+		t.out.WriteRune(r)
+	case '[':
+		return t.classExpr()
+	case '\\':
+		if t.isCategory() {
+			t.out.WriteByte('[')
+			if err := t.category(); err != nil {
+				return err
+			}
+			t.out.WriteByte(']')
+			return nil
+		}
+		r, err := t.singleCharEscape()
+		if err != nil {
+			return err
+		}
+		writeRE2Rune(&t.out, r)
+	case '*', '+', '?', '{':
+		return t.fail(fmt.Sprintf("missing operand for %c", r))
+	case ']', '}':
+		return t.fail(fmt.Sprintf("unescaped %c", r))
+	default:
+		t.pos++
+		writeRE2Rune(&t.out, r)
+	}
+	return nil
+}
+
+// quantifier translates an optional quantifier. I-Regexp has no lazy or
+// possessive quantifiers, so a quantifier can't follow another
+func (t *iregexp) quantifier() error {
+	switch t.peek() {
+	case '*', '+', '?':
+		t.out.WriteRune(t.src[t.pos])
+		t.pos++
+	case '{':
+		if err := t.rangeQuantifier(); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	switch t.peek() {
+	case '*', '+', '?', '{':
+		return t.fail("quantifier must follow an atom")
+	}
+	return nil
+}
+
+func (t *iregexp) rangeQuantifier() error {
+	start := t.pos
+	t.pos++
+	lo, ok := t.quantExact()
+	if !ok {
+		return t.failAt(start, "invalid repetition")
+	}
+	hi := lo
+	if t.consume(',') {
+		hi = -1
+		if t.peek() != '}' {
+			if hi, ok = t.quantExact(); !ok {
+				return t.failAt(start, "invalid repetition")
+			}
+		}
+	}
+	if !t.consume('}') {
+		return t.failAt(start, "invalid repetition")
+	}
+	switch {
+	case hi < 0:
+		fmt.Fprintf(&t.out, "{%d,}", lo)
+	case hi < lo:
+		return t.failAt(start, "invalid repetition range")
+	case hi == lo:
+		fmt.Fprintf(&t.out, "{%d}", lo)
+	default:
+		fmt.Fprintf(&t.out, "{%d,%d}", lo, hi)
+	}
+	return nil
+}
+
+func (t *iregexp) quantExact() (int, bool) {
+	start := t.pos
+	for r := t.peek(); r >= '0' && r <= '9'; r = t.peek() {
+		t.pos++
+	}
+	n, err := strconv.Atoi(string(t.src[start:t.pos]))
+	return n, err == nil
+}
+
+func (t *iregexp) classExpr() error {
+	start := t.pos
+	t.pos++
+	t.out.WriteByte('[')
+	if t.consume('^') {
+		t.out.WriteByte('^')
+	}
+	first := true
+	for {
+		switch t.peek() {
+		case -1:
+			return t.failAt(start, "missing ]")
+		case ']':
+			if first {
+				return t.fail("empty character class")
+			}
+			t.pos++
+			t.out.WriteByte(']')
+			return nil
+		case '-':
+			// a literal hyphen may only begin or end a class
+			if !first && t.peekAt(1) != ']' {
+				return t.fail("unescaped -")
+			}
+			t.pos++
+			writeRE2Rune(&t.out, '-')
+		default:
+			if err := t.classItem(); err != nil {
+				return err
+			}
+		}
+		first = false
+	}
+}
+
+func (t *iregexp) classItem() error {
+	if t.isCategory() {
+		return t.category()
+	}
+	lo, err := t.classChar()
+	if err != nil {
+		return err
+	}
+	writeRE2Rune(&t.out, lo)
+	if t.peek() != '-' || t.peekAt(1) == ']' {
+		return nil
+	}
+	start := t.pos
+	t.pos++
+	if t.isCategory() {
+		return t.failAt(start, "invalid character class range")
+	}
+	hi, err := t.classChar()
+	if err != nil {
+		return err
+	}
+	if hi < lo {
+		return t.failAt(start, "invalid character class range")
+	}
+	t.out.WriteByte('-')
+	writeRE2Rune(&t.out, hi)
+	return nil
+}
+
+func (t *iregexp) classChar() (rune, error) {
+	switch r := t.peek(); r {
+	case -1:
+		return 0, t.fail("missing ]")
+	case '\\':
+		return t.singleCharEscape()
+	case '[', ']', '-':
+		return 0, t.fail(fmt.Sprintf("unescaped %c", r))
+	default:
+		t.pos++
+		return r, nil
+	}
+}
+
+func (t *iregexp) singleCharEscape() (rune, error) {
+	start := t.pos
+	t.pos++
+	r := t.peek()
+	t.pos++
+	switch r {
+	case '(', ')', '*', '+', '-', '.', '?', '[', '\\', ']', '^', '{',
+		'|', '}':
+		return r, nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case -1:
+		return 0, t.failAt(start, "trailing \\")
+	default:
+		return 0, t.failAt(
+			start, fmt.Sprintf("unsupported escape \\%c", r),
+		)
+	}
+}
+
+func (t *iregexp) isCategory() bool {
+	if t.peek() != '\\' {
+		return false
+	}
+	r := t.peekAt(1)
+	return r == 'p' || r == 'P'
+}
+
+// category translates a \p{..} or \P{..} escape into the body of an RE2
+// character class
+func (t *iregexp) category() error {
+	start := t.pos
+	negated := t.src[t.pos+1] == 'P'
+	t.pos += 2
+	if !t.consume('{') {
+		return t.failAt(start, "invalid character property")
+	}
+	nameStart := t.pos
+	for r := t.peek(); r != '}' && r != -1; r = t.peek() {
+		t.pos++
+	}
+	name := string(t.src[nameStart:t.pos])
+	if !t.consume('}') {
+		return t.failAt(start, "invalid character property")
+	}
+	if !iregexpCategories[name] {
+		return t.failAt(
+			start, fmt.Sprintf("unknown character category %s", name),
+		)
+	}
+	switch {
+	case name == "Cn" && negated:
+		t.out.WriteString(assignedClass)
+	case name == "Cn":
+		t.out.WriteString(unassignedRanges())
+	case negated:
+		fmt.Fprintf(&t.out, `\P{%s}`, name)
+	default:
+		fmt.Fprintf(&t.out, `\p{%s}`, name)
+	}
+	return nil
+}
+
+func (t *iregexp) fail(msg string) error {
+	return t.failAt(t.pos, msg)
+}
+
+func (t *iregexp) failAt(pos int, msg string) error {
+	return fmt.Errorf("%s at offset %d", msg, pos)
+}
+
+func (t *iregexp) eof() bool {
+	return t.pos >= len(t.src)
+}
+
+func (t *iregexp) peek() rune {
+	return t.peekAt(0)
+}
+
+func (t *iregexp) peekAt(n int) rune {
+	if t.pos+n >= len(t.src) {
+		return -1
+	}
+	return t.src[t.pos+n]
+}
+
+func (t *iregexp) consume(r rune) bool {
+	if t.peek() != r {
+		return false
+	}
+	t.pos++
+	return true
+}
+
+// writeRE2Rune writes a rune that RE2 matches literally, both inside and
+// outside of a character class
+func writeRE2Rune(b *strings.Builder, r rune) {
+	switch {
+	case r < ' ' || r == unicode.MaxASCII:
+		fmt.Fprintf(b, `\x{%x}`, r)
+		return
+	case r < unicode.MaxASCII && !isDigit(r) && !unicode.IsLetter(r):
+		b.WriteByte('\\')
+	}
+	b.WriteRune(r)
+}
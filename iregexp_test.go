@@ -0,0 +1,139 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestIRegexpMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		matches []string
+		misses  []string
+	}{
+		{`a.c`, []string{"abc", "a\u2028c"}, []string{"a\nc", "a\rc"}},
+		{`a[.]c`, []string{"a.c"}, []string{"abc"}},
+		{`a\.c|x`, []string{"a.c", "x"}, []string{"abc", "a.cx"}},
+		{`(ab)+`, []string{"ab", "abab"}, []string{"", "aba"}},
+		{`a{2}`, []string{"aa"}, []string{"a", "aaa"}},
+		{`a{1,2}b{2,}`, []string{"abb", "aabbb"}, []string{"ab", "aaabb"}},
+		{`[a-c-]+`, []string{"ab-c", "-"}, []string{"d"}},
+		{`[-x]`, []string{"-", "x"}, []string{"a"}},
+		{`[^a-z]`, []string{"A", "1", "\n"}, []string{"b"}},
+		{`[--]`, []string{"-"}, []string{"a"}},
+		{`[\^\]\-\\]+`, []string{`^]-\`}, []string{"a"}},
+		{`[$^]+`, []string{"$^"}, []string{"a"}},
+		{`\n\r\t`, []string{"\n\r\t"}, []string{`\n\r\t`}},
+		{`\(\)\*\+\?\{\}\|`, []string{"()*+?{}|"}, []string{""}},
+		{`a b#`, []string{"a b#"}, []string{"ab#"}},
+		{`\p{Lu}\P{Lu}`, []string{"\u04161"}, []string{"\u0436\u0416"}},
+		{`\p{L}+`, []string{"h\u00e9llo"}, []string{"h3"}},
+		{`[\p{Nd}x]+`, []string{"x\u06631"}, []string{"a"}},
+		{`\p{Cn}`, []string{"\u0378"}, []string{"a", "\u0000", "\ue000"}},
+		{`\P{Cn}`, []string{"a", "\u0000", "\ue000"}, []string{"\u0378"}},
+		{`[^\p{Cn}a]`, []string{"b"}, []string{"a", "\u0378"}},
+		{`\p{C}`, []string{"\u0378", "\u0000", "\ue000"}, []string{"a"}},
+		{`\P{C}`, []string{"a"}, []string{"\u0378", "\u0000"}},
+		{`^ab.*`, []string{"abc", "ab"}, []string{"xab"}},
+		{`.*bc$`, []string{"abc"}, []string{"abcx"}},
+		{`a|`, []string{"a", ""}, []string{"b"}},
+		{`()`, []string{""}, []string{"a"}},
+	}
+	for _, c := range cases {
+		query := `$[?match(@, '` + escapeQuery(c.pattern) + `')]`
+		path, err := jpath.Compile(jpath.MustParse(query))
+		if !assert.NoError(t, err, c.pattern) {
+			continue
+		}
+		for _, s := range c.matches {
+			assert.Len(t, path([]any{s}), 1, "%s matching %q", c.pattern, s)
+		}
+		for _, s := range c.misses {
+			assert.Empty(t, path([]any{s}), "%s matching %q", c.pattern, s)
+		}
+	}
+}
+
+func TestIRegexpSearch(t *testing.T) {
+	doc := []any{"xaby", "ab", "a\nb", "ba"}
+	cases := map[string][]any{
+		`$[?search(@, 'a.')]`:      {"xaby", "ab"},
+		`$[?search(@, '^a')]`:      {"ab", "a\nb"},
+		`$[?search(@, 'b$')]`:      {"ab", "a\nb"},
+		`$[?search(@, '[a][b]')]`:  {"xaby", "ab"},
+		`$[?search(@, '\\p{Ll}')]`: doc,
+	}
+	assertQueries(t, jpath.NewRegistry(), doc, cases)
+}
+
+func TestIRegexpErrors(t *testing.T) {
+	cases := map[string]string{
+		`\d`:                      `unsupported escape \d at offset 0`,
+		`a\w`:                     `unsupported escape \w at offset 1`,
+		`\b`:                      `unsupported escape \b`,
+		`\S`:                      `unsupported escape \S`,
+		"\\u00e9":                 `unsupported escape \u`,
+		`a\`:                      `trailing \ at offset 1`,
+		`(?i)a`:                   `missing operand for ? at offset 1`,
+		`(?:a)`:                   `missing operand for ?`,
+		`*a`:                      `missing operand for *`,
+		`a|+`:                     `missing operand for +`,
+		`a*?`:                     `quantifier must follow an atom at offset 2`,
+		`a+*`:                     `quantifier must follow an atom`,
+		`a{2}{3}`:                 `quantifier must follow an atom`,
+		`a{2,1}`:                  `invalid repetition range at offset 1`,
+		`a{,2}`:                   `invalid repetition at offset 1`,
+		`a{2`:                     `invalid repetition`,
+		`a{x}`:                    `invalid repetition`,
+		`a{99999999999999999999}`: `invalid repetition`,
+		`a{1001}`:                 `invalid repeat count`,
+		`(a`:                      `missing ) at offset 2`,
+		`a)`:                      `unmatched ) at offset 1`,
+		`a]`:                      `unescaped ]`,
+		`a}`:                      `unescaped }`,
+		`[]`:                      `empty character class at offset 1`,
+		`[^]`:                     `empty character class`,
+		`[a`:                      `missing ] at offset 0`,
+		`[a-`:                     `missing ]`,
+		`[a-z-q]`:                 `unescaped - at offset 4`,
+		`[a--]`:                   `unescaped -`,
+		`[[a]]`:                   `unescaped [`,
+		`[z-a]`:                   `invalid character class range at offset 2`,
+		`[a-\p{L}]`:               `invalid character class range`,
+		`[\d]`:                    `unsupported escape \d`,
+		`\p{Cs}`:                  `unknown character category Cs at offset 0`,
+		`\p{Lx}`:                  `unknown character category Lx`,
+		`\p{IsBasicLatin}`:        `unknown character category IsBasicLatin`,
+		`\pL`:                     `invalid character property at offset 0`,
+		`\p{L`:                    `invalid character property`,
+	}
+	for pattern, want := range cases {
+		for _, fn := range []string{"match", "search"} {
+			query := `$[?` + fn + `(@, '` + escapeQuery(pattern) + `')]`
+			_, err := jpath.Query(query, nil)
+			assert.ErrorIs(t, err, jpath.ErrBadPattern, query)
+			assert.Equal(t, jpath.ErrBadPattern, queryError(t, err).Code)
+			if assert.Error(t, err, query) {
+				assert.Contains(t, err.Error(), want, query)
+			}
+		}
+	}
+}
+
+func escapeQuery(pattern string) string {
+	res := make([]rune, 0, len(pattern))
+	for _, r := range pattern {
+		switch r {
+		case '\\', '\'':
+			res = append(res, '\\', r)
+		case '\n':
+			res = append(res, '\\', 'n')
+		default:
+			res = append(res, r)
+		}
+	}
+	return string(res)
+}
@@ -0,0 +1,55 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestValuesIterator(t *testing.T) {
+	calls := 0
+	reg := probeRegistry(&calls)
+	doc := wideDocument()
+	path := reg.MustCompileNodes(reg.MustParse("$.items[?probe(@.id) >= 0].id"))
+
+	var got []any
+	for v := range path.Values(doc) {
+		got = append(got, v)
+		if len(got) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []any{0.0, 1.0, 2.0}, got)
+	assert.Equal(t, 3, calls)
+
+	count := 0
+	for range path.Values(doc) {
+		count++
+	}
+	assert.Equal(t, 100, count)
+}
+
+func TestAllIterator(t *testing.T) {
+	doc := map[string]any{
+		"a": []any{1.0, map[string]any{"b": 2.0}},
+	}
+	path := jpath.MustCompileNodes(jpath.MustParse("$..*"))
+
+	var paths []string
+	var values []any
+	for loc, v := range path.All(doc) {
+		paths = append(paths, loc.String())
+		values = append(values, v)
+	}
+	assert.Equal(t, []string{
+		"$['a']", "$['a'][0]", "$['a'][1]", "$['a'][1]['b']",
+	}, paths)
+	assert.Equal(t, 2.0, values[3])
+
+	for loc := range path.All(doc) {
+		assert.Equal(t, jpath.Location{"a"}, loc)
+		break
+	}
+}
@@ -1,5 +1,10 @@
 package jpath
 
+import (
+	"context"
+	"io"
+)
+
 var defaultRegistry = NewRegistry()
 
 // Parse parses a JSONPath query into a PathExpr syntax tree
@@ -7,6 +12,12 @@ func Parse(query string) (*PathExpr, error) {
 	return defaultRegistry.Parse(query)
 }
 
+// ParseRecover parses a JSONPath query, reporting every syntax and
+// validation error it contains rather than only the first
+func ParseRecover(query string) (*PathExpr, error) {
+	return defaultRegistry.ParseRecover(query)
+}
+
 // MustParse parses a JSONPath query or panics
 func MustParse(query string) *PathExpr {
 	return defaultRegistry.MustParse(query)
@@ -22,6 +33,27 @@ func MustCompile(path *PathExpr) Path {
 	return defaultRegistry.MustCompile(path)
 }
 
+// CompileNodes compiles a parsed PathExpr into an executable NodePath
+func CompileNodes(path *PathExpr) (*NodePath, error) {
+	return defaultRegistry.CompileNodes(path)
+}
+
+// MustCompileNodes compiles a parsed PathExpr into a NodePath or panics
+func MustCompileNodes(path *PathExpr) *NodePath {
+	return defaultRegistry.MustCompileNodes(path)
+}
+
+// CompileQuery parses and compiles a JSONPath query into a NodePath,
+// reporting a failure at either step as a *QueryError
+func CompileQuery(query string) (*NodePath, error) {
+	return defaultRegistry.CompileQuery(query)
+}
+
+// CompileStream compiles a parsed PathExpr into an executable StreamPath
+func CompileStream(path *PathExpr) (*StreamPath, error) {
+	return defaultRegistry.CompileStream(path)
+}
+
 // Query parses and compiles a JSONPath query, then runs it on a document
 func Query(query string, document any) ([]any, error) {
 	return defaultRegistry.Query(query, document)
@@ -31,3 +63,55 @@ func Query(query string, document any) ([]any, error) {
 func MustQuery(query string, document any) []any {
 	return defaultRegistry.MustQuery(query, document)
 }
+
+// QueryContext parses and compiles a JSONPath query, then runs it on a
+// document within the budgets set by opts, stopping when ctx is done
+func QueryContext(
+	ctx context.Context, query string, document any, opts EvalOptions,
+) ([]any, error) {
+	return defaultRegistry.QueryContext(ctx, query, document, opts)
+}
+
+// Locate parses and compiles a JSONPath query, then runs it on a document,
+// returning every match along with its normalized path
+func Locate(query string, document any) (NodeList, error) {
+	return defaultRegistry.Locate(query, document)
+}
+
+// MustLocate parses, compiles, and locates a JSONPath query or panics
+func MustLocate(query string, document any) NodeList {
+	return defaultRegistry.MustLocate(query, document)
+}
+
+// Exists parses and compiles a JSONPath query, then reports whether it
+// matches anything in a document
+func Exists(query string, document any) (bool, error) {
+	return defaultRegistry.Exists(query, document)
+}
+
+// Stream parses and compiles a JSONPath query, then runs it against the
+// JSON document read from in, reporting each match to yield as it is found
+func Stream(query string, in io.Reader, yield func(*Node) bool) error {
+	return defaultRegistry.Stream(query, in, yield)
+}
+
+// Set assigns value at every match of a JSONPath query, creating missing
+// members named by a final name-only segment
+func Set(query string, document, value any) (any, int, error) {
+	return defaultRegistry.Set(query, document, value)
+}
+
+// Replace assigns value at every existing match of a JSONPath query
+func Replace(query string, document, value any) (any, int, error) {
+	return defaultRegistry.Replace(query, document, value)
+}
+
+// Update replaces every match of a JSONPath query with the result of fn
+func Update(query string, document any, fn UpdateFunc) (any, int, error) {
+	return defaultRegistry.Update(query, document, fn)
+}
+
+// Delete removes every match of a JSONPath query
+func Delete(query string, document any) (any, int, error) {
+	return defaultRegistry.Delete(query, document)
+}
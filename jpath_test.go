@@ -65,7 +65,7 @@ func TestTopLevelFilterShorthand(t *testing.T) {
 	if !assert.NoError(t, err) {
 		return
 	}
-	assert.Equal(t, explicitAST, shorthandAST)
+	assert.Equal(t, clearSpans(explicitAST), clearSpans(shorthandAST))
 
 	docMatch := map[string]any{
 		"product_info": map[string]any{
@@ -111,7 +111,7 @@ func TestTopLevelFilterShorthandCurrentNode(t *testing.T) {
 	if !assert.NoError(t, err) {
 		return
 	}
-	assert.Equal(t, explicitAST, shorthandAST)
+	assert.Equal(t, clearSpans(explicitAST), clearSpans(shorthandAST))
 
 	doc := []any{
 		map[string]any{"name": "Professional Laptop"},
@@ -0,0 +1,204 @@
+package jpath
+
+// Dialect selects the query syntax accepted by a Parser
+type Dialect int
+
+const (
+	// DialectRFC9535 accepts only RFC 9535 queries
+	DialectRFC9535 Dialect = iota
+
+	// DialectLegacy also accepts the pre-RFC syntax of the Goessner and
+	// Jayway implementations, lowering it to the standard syntax tree
+	DialectLegacy
+)
+
+// Rewrite describes a legacy construct that was lowered to RFC 9535
+type Rewrite struct {
+	// Span locates the legacy construct in the query
+	Span Span
+
+	// Legacy is the text of the legacy construct
+	Legacy string
+
+	// Standard is the RFC 9535 text that replaces it
+	Standard string
+
+	// Note explains how results may differ from those of the legacy
+	// implementations. It is empty when the rewrite is purely syntactic
+	Note string
+}
+
+const (
+	noteLength = "length() counts the members of an object, rather " +
+		"than selecting a member named length"
+	noteIn  = "values are compared with ==, so 1 does not match '1'"
+	noteNin = "values are compared with !=, so 1 does not match '1', " +
+		"and a node without the left-hand value matches"
+)
+
+// Translate rewrites a legacy query into RFC 9535 syntax. Along with the
+// new query text, it returns every rewrite it made, in order
+func Translate(query string) (string, []Rewrite, error) {
+	p := Parser{Options: ParserOptions{Dialect: DialectLegacy}}
+	path, err := p.Parse(query)
+	if err != nil {
+		return "", nil, err
+	}
+	return path.String(), p.rewrites, nil
+}
+
+// parseLegacySelector parses the bracketed selectors only accepted by the
+// legacy dialect: unquoted member names, and (@.length-N) script indexes
+func (p *Parser) parseLegacySelector() (*SelectorExpr, bool, error) {
+	start := p.pos
+	if name, ok := p.parseMemberName(); ok {
+		sel := &SelectorExpr{Kind: SelectorName, Name: name}
+		p.rewrite(p.span(start), sel.String(), "")
+		return sel, true, nil
+	}
+	if !p.consume('(') {
+		return nil, false, nil
+	}
+	p.skipWS()
+	if !p.consumeString("@.length") {
+		return nil, true, wrapPathError(
+			p.text, p.pos, ErrUnexpectedToken, "@.length",
+		)
+	}
+	p.skipWS()
+	if !p.consume('-') {
+		return nil, true, wrapPathError(p.text, p.pos, ErrUnexpectedToken, "-")
+	}
+	p.skipWS()
+	pos := p.pos
+	n, ok := p.parseIntLiteral()
+	if !ok || n < 1 {
+		return nil, true, wrapPathError(
+			p.text, pos, ErrUnexpectedToken, tokenInteger,
+		)
+	}
+	p.skipWS()
+	if !p.consume(')') {
+		return nil, true, wrapPathError(p.text, p.pos, ErrUnexpectedToken, ")")
+	}
+	sel := &SelectorExpr{Kind: SelectorIndex, Index: -n}
+	p.rewrite(p.span(start), sel.String(), "")
+	return sel, true, nil
+}
+
+// parseLegacyMembership parses an in or nin test of left against a list
+// of literals, lowering it to a chain of comparisons
+func (p *Parser) parseLegacyMembership(
+	left FilterExpr,
+) (FilterExpr, bool, error) {
+	op, join, note := "==", "||", noteIn
+	switch {
+	case p.consumeKeyword("in"):
+	case p.consumeKeyword("nin"):
+		op, join, note = "!=", "&&", noteNin
+	default:
+		return nil, false, nil
+	}
+	left = p.lowerLength(left)
+	p.skipWS()
+	if !p.consume('[') {
+		return nil, true, wrapPathError(p.text, p.pos, ErrUnexpectedToken, "[")
+	}
+	var res FilterExpr
+	for {
+		p.skipWS()
+		pos := p.pos
+		ex, err := p.parsePrimary()
+		if err != nil {
+			return nil, true, err
+		}
+		if _, ok := ex.(*LiteralExpr); !ok {
+			return nil, true, wrapPathError(
+				p.text, pos, ErrUnexpectedToken,
+				tokenString, tokenNumber, "true", "false", "null",
+			)
+		}
+		cmp := binaryExpr(op, left, ex)
+		if res == nil {
+			res = cmp
+		} else {
+			res = binaryExpr(join, res, cmp)
+		}
+		p.skipWS()
+		if p.consume(']') {
+			break
+		}
+		if !p.consume(',') {
+			return nil, true, wrapPathError(
+				p.text, p.pos, ErrUnexpectedToken, "]", ",",
+			)
+		}
+	}
+	sp := Span{Start: left.span().Start, End: p.span(p.pos).End}
+	setSpan(res, sp)
+	p.rewrite(sp, formatFilter(res), note)
+	return res, true, nil
+}
+
+// lowerLength rewrites a legacy .length property read on a compared path
+// into a call to the length function
+func (p *Parser) lowerLength(ex FilterExpr) FilterExpr {
+	pv, ok := ex.(*PathValueExpr)
+	if !ok || !p.Options.legacy() {
+		return ex
+	}
+	segs := pv.Path.Segments
+	if len(segs) == 0 {
+		return ex
+	}
+	last := segs[len(segs)-1]
+	if last.Descendant || len(last.Selectors) != 1 {
+		return ex
+	}
+	sel := last.Selectors[0]
+	if sel.Kind != SelectorName || sel.Name != "length" {
+		return ex
+	}
+	if q := p.src[sel.Span.Start]; q == '\'' || q == '"' {
+		return ex
+	}
+	path := &PathExpr{
+		Segments: segs[:len(segs)-1],
+		Span:     Span{Start: pv.Path.Span.Start, End: last.Span.Start},
+	}
+	res := &FuncExpr{
+		Name: "length",
+		Args: []FilterExpr{
+			&PathValueExpr{
+				Absolute: pv.Absolute,
+				Path:     path,
+				Span:     path.Span,
+			},
+		},
+		Span: pv.Span,
+	}
+	p.rewrite(pv.Span, res.String(), noteLength)
+	return res
+}
+
+func (p *Parser) rewrite(sp Span, standard, note string) {
+	p.rewrites = append(p.rewrites, Rewrite{
+		Span:     sp,
+		Legacy:   string(p.src[sp.Start:sp.End]),
+		Standard: standard,
+		Note:     note,
+	})
+}
+
+// consumeKeyword consumes v when it is not followed by more of a name
+func (p *Parser) consumeKeyword(v string) bool {
+	start := p.pos
+	if !p.consumeString(v) {
+		return false
+	}
+	if !p.eof() && isNamePart(p.peek()) {
+		p.pos = start
+		return false
+	}
+	return true
+}
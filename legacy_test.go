@@ -0,0 +1,119 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestTranslate(t *testing.T) {
+	cases := map[string]string{
+		`$..b[?(@.price < 10)]`:       `$..b[?@.price < 10]`,
+		`$..b[-1:]`:                   `$..b[-1:]`,
+		`$..b[(@.length-1)]`:          `$..b[-1]`,
+		`$..b[( @.length - 2 )]`:      `$..b[-2]`,
+		`$.a[b][0][c, d]`:             `$.a.b[0]['c', 'd']`,
+		`$[?(@.tags.length > 1)]`:     `$[?length(@.tags) > 1]`,
+		`$[?(2 <= @.tags.length)]`:    `$[?2 <= length(@.tags)]`,
+		`$[?(@.tags['length'] > 1)]`:  `$[?@.tags.length > 1]`,
+		`$[?(@.length)]`:              `$[?@.length]`,
+		`$[?(@.a in ['S', 'M'])]`:     `$[?@.a == 'S' || @.a == 'M']`,
+		`$[?(@.a nin ['S']) && @.b]`:  `$[?@.a != 'S' && @.b]`,
+		`$[?(@.a nin [1, 2] || @.b)]`: `$[?@.a != 1 && @.a != 2 || @.b]`,
+		`$[?(@.a in [1, 2] && @.b)]`:  `$[?(@.a == 1 || @.a == 2) && @.b]`,
+		`$[?(@.a.length in [1, 2])]`: `$[?length(@.a) == 1 || ` +
+			`length(@.a) == 2]`,
+	}
+	for query, want := range cases {
+		got, _, err := jpath.Translate(query)
+		if assert.NoError(t, err, query) {
+			assert.Equal(t, want, got, query)
+		}
+	}
+}
+
+func TestTranslateRewrites(t *testing.T) {
+	query := `$.store[book][?(@.tags.length > 1 && @.size nin ['S'])]`
+	_, rws, err := jpath.Translate(query)
+	if !assert.NoError(t, err) || !assert.Len(t, rws, 3) {
+		return
+	}
+
+	assert.Equal(t, "book", rws[0].Legacy)
+	assert.Equal(t, "'book'", rws[0].Standard)
+	assert.Empty(t, rws[0].Note)
+
+	assert.Equal(t, "@.tags.length", rws[1].Legacy)
+	assert.Equal(t, "length(@.tags)", rws[1].Standard)
+	assert.Contains(t, rws[1].Note, "counts the members of an object")
+
+	assert.Equal(t, "@.size nin ['S']", rws[2].Legacy)
+	assert.Equal(t, "@.size != 'S'", rws[2].Standard)
+	assert.Contains(t, rws[2].Note, "without the left-hand value matches")
+	assert.Equal(t, "@.size nin ['S']", spanText(query, rws[2].Span))
+}
+
+func TestTranslateErrors(t *testing.T) {
+	cases := map[string]error{
+		`$[(@.length-0)]`:    jpath.ErrUnexpectedToken,
+		`$[(@.size-1)]`:      jpath.ErrUnexpectedToken,
+		`$[(@.length+1)]`:    jpath.ErrUnexpectedToken,
+		`$[?(@.a in [@.b])]`: jpath.ErrUnexpectedToken,
+		`$[?(@.a in 'x')]`:   jpath.ErrUnexpectedToken,
+		`$[?(@.a in [1 2])]`: jpath.ErrUnexpectedToken,
+		`$[?(@.a in [1,`:     jpath.ErrUnexpectedToken,
+	}
+	for query, want := range cases {
+		_, _, err := jpath.Translate(query)
+		assert.ErrorIs(t, err, want, query)
+		assert.ErrorIs(t, err, jpath.ErrInvalidPath, query)
+	}
+}
+
+func TestLegacyDialect(t *testing.T) {
+	doc := map[string]any{
+		"book": []any{
+			map[string]any{"size": "S", "tags": []any{"a"}},
+			map[string]any{"size": "M", "tags": []any{"a", "b"}},
+			map[string]any{"size": "L", "tags": []any{}},
+		},
+	}
+	books := doc["book"].([]any)
+	cases := map[string][]any{
+		`$[book][(@.length-1)]`:                    {books[2]},
+		`$.book[?(@.size in ['S', 'L'])]`:          {books[0], books[2]},
+		`$.book[?(@.size nin ['S', 'L'])]`:         {books[1]},
+		`$.book[?(@.tags.length >= 1)].size`:       {"S", "M"},
+		`$.book[?(@.tags.length in [0, 2])][size]`: {"M", "L"},
+	}
+	p := jpath.Parser{
+		Options: jpath.ParserOptions{Dialect: jpath.DialectLegacy},
+	}
+	for query, want := range cases {
+		ast, err := p.Parse(query)
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+		path, err := jpath.Compile(ast)
+		if assert.NoError(t, err, query) {
+			assert.Equal(t, want, path(doc), query)
+		}
+	}
+}
+
+func TestLegacyRequiresDialect(t *testing.T) {
+	for _, query := range []string{
+		`$[book]`,
+		`$[(@.length-1)]`,
+		`$[?(@.size in ['S'])]`,
+	} {
+		_, err := jpath.Parse(query)
+		assert.ErrorIs(t, err, jpath.ErrUnexpectedToken, query)
+	}
+
+	// A legacy .length is only a member name under RFC 9535
+	ast := jpath.MustParse(`$[?@.tags.length > 1]`)
+	assert.Equal(t, `$[?@.tags.length > 1]`, ast.String())
+}
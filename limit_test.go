@@ -0,0 +1,99 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func probeRegistry(calls *int) *jpath.Registry {
+	reg := jpath.NewRegistry()
+	reg.MustRegisterFunction("probe", 1, func(args ...any) (any, bool) {
+		*calls++
+		return args[0], true
+	})
+	return reg
+}
+
+func wideDocument() any {
+	items := make([]any, 100)
+	for idx := range items {
+		items[idx] = map[string]any{
+			"id":   float64(idx),
+			"tags": []any{"a", "b"},
+		}
+	}
+	return map[string]any{"items": items}
+}
+
+func TestExistsStopsEarly(t *testing.T) {
+	calls := 0
+	reg := probeRegistry(&calls)
+	doc := wideDocument()
+
+	cases := map[string]bool{
+		"$..[?probe(@.id) >= 0]":   true,
+		"$..[?probe(@.id) > 1000]": false,
+	}
+	for query, want := range cases {
+		calls = 0
+		ok, err := reg.Exists(query, doc)
+		assert.NoError(t, err, query)
+		assert.Equal(t, want, ok, query)
+		if want {
+			assert.Less(t, calls, 5, query)
+		}
+	}
+
+	_, err := reg.Exists("$[", doc)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+
+	ok, err := jpath.Exists("$.items[0].id", doc)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestFirstAndLimit(t *testing.T) {
+	calls := 0
+	reg := probeRegistry(&calls)
+	doc := wideDocument()
+	path := reg.MustCompileNodes(reg.MustParse("$.items[?probe(@.id) > 9].id"))
+
+	first, ok := path.First(doc)
+	assert.True(t, ok)
+	assert.Equal(t, float64(10), first.Value)
+	assert.Equal(t, "$['items'][10]['id']", first.Path())
+	assert.Equal(t, 11, calls)
+
+	calls = 0
+	got := path.Limit(doc, 3)
+	assert.Equal(t, []any{10.0, 11.0, 12.0}, got.Values())
+	assert.Equal(t, 13, calls)
+
+	assert.Empty(t, path.Limit(doc, 0))
+	assert.Len(t, path.Limit(doc, 1000), 90)
+
+	_, ok = path.First(map[string]any{})
+	assert.False(t, ok)
+}
+
+func TestFilterExistenceStopsEarly(t *testing.T) {
+	calls := 0
+	reg := probeRegistry(&calls)
+	doc := map[string]any{"a": wideDocument(), "b": "x"}
+
+	reg.MustQuery("$.a..[?probe(@) == 'a']", doc)
+	full := calls
+
+	cases := map[string][]any{
+		"$[?@..[?probe(@) == 'a']]":  {doc["a"]},
+		"$[?!@..[?probe(@) == 'a']]": {"x"},
+	}
+	for query, want := range cases {
+		calls = 0
+		assertRegistryQuery(t, reg, query, doc, want)
+		assert.Less(t, calls, full/2, query)
+	}
+}
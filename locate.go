@@ -0,0 +1,284 @@
+package jpath
+
+import "iter"
+
+type (
+	// NodePath is a compiled query that produces matched nodes along with
+	// their normalized paths. It shares filter evaluation with Path, but
+	// tracks the position of every node it visits
+	NodePath struct {
+		run    nodeSegment
+		parent nodeSegment
+		names  []string
+	}
+
+	nodeYield    func(*Node) bool
+	nodeSegment  func(node *Node, env *evalEnv, yield nodeYield) bool
+	nodeSelector func(node *Node, env *evalEnv, yield nodeYield) bool
+)
+
+// Locate executes the query against a document and returns every match
+// with its normalized path
+func (p *NodePath) Locate(document any) NodeList {
+	res := NodeList{}
+	p.each(document, func(n *Node) bool {
+		res = append(res, n)
+		return true
+	})
+	return res
+}
+
+// Exists reports whether the query matches anything in a document. It
+// stops traversing the document at the first match
+func (p *NodePath) Exists(document any) bool {
+	_, ok := p.First(document)
+	return ok
+}
+
+// First returns the first match of the query in a document, stopping
+// traversal as soon as it is found
+func (p *NodePath) First(document any) (*Node, bool) {
+	var res *Node
+	p.each(document, func(n *Node) bool {
+		res = n
+		return false
+	})
+	return res, res != nil
+}
+
+// Limit returns at most n matches of the query in a document, stopping
+// traversal once they have been found
+func (p *NodePath) Limit(document any, n int) NodeList {
+	res := NodeList{}
+	if n <= 0 {
+		return res
+	}
+	p.each(document, func(node *Node) bool {
+		res = append(res, node)
+		return len(res) < n
+	})
+	return res
+}
+
+// Values returns an iterator over the values matched in a document. Matches
+// are produced lazily, so breaking out of a range loop stops traversal
+func (p *NodePath) Values(document any) iter.Seq[any] {
+	return func(yield func(any) bool) {
+		p.each(document, func(n *Node) bool {
+			return yield(n.Value)
+		})
+	}
+}
+
+// All returns an iterator over the matches in a document, paired with
+// their locations. Matches are produced lazily, so breaking out of a range
+// loop stops traversal
+func (p *NodePath) All(document any) iter.Seq2[Location, any] {
+	return func(yield func(Location, any) bool) {
+		p.each(document, func(n *Node) bool {
+			return yield(n.Location(), n.Value)
+		})
+	}
+}
+
+func (p *NodePath) each(document any, yield nodeYield) {
+	p.run(RootNode(document), newEvalEnv(document), yield)
+}
+
+// runFrom evaluates the query as a subquery starting at node, which sits
+// at depth base in the document being evaluated
+func (p *NodePath) runFrom(
+	node any, base int, env *evalEnv, yield nodeYield,
+) {
+	saved := env.base
+	env.base = base
+	p.run(RootNode(node), env, yield)
+	env.base = saved
+}
+
+func composeNodePath(segments []nodeSegment) *NodePath {
+	return &NodePath{run: composeNodeSegments(segments)}
+}
+
+func (p *NodePath) withTrailingNames(
+	segments []nodeSegment, names []string,
+) *NodePath {
+	if len(names) == 0 {
+		return p
+	}
+	p.parent = composeNodeSegments(segments[:len(segments)-1])
+	p.names = names
+	return p
+}
+
+func composeNodeSegments(segments []nodeSegment) nodeSegment {
+	chain := nodeSegmentIdentity
+	for idx := len(segments) - 1; idx >= 0; idx-- {
+		current := segments[idx]
+		next := chain
+		chain = func(node *Node, env *evalEnv, yield nodeYield) bool {
+			return current(node, env, func(n *Node) bool {
+				return next(n, env, yield)
+			})
+		}
+	}
+	return chain
+}
+
+func nodeSegmentIdentity(node *Node, _ *evalEnv, yield nodeYield) bool {
+	return yield(node)
+}
+
+func composeNodeSegment(
+	a Adapter, selectors []nodeSelector, descendant bool,
+) nodeSegment {
+	if descendant {
+		return func(node *Node, env *evalEnv, yield nodeYield) bool {
+			return walkNodeDescendants(a, node, env, func(n *Node) bool {
+				return applyNodeSelectors(selectors, n, env, yield)
+			})
+		}
+	}
+	return func(node *Node, env *evalEnv, yield nodeYield) bool {
+		return applyNodeSelectors(selectors, node, env, yield)
+	}
+}
+
+func applyNodeSelectors(
+	selectors []nodeSelector, node *Node, env *evalEnv, yield nodeYield,
+) bool {
+	for _, sel := range selectors {
+		if !sel(node, env, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+func walkNodeDescendants(
+	a Adapter, node *Node, env *evalEnv, visit nodeYield,
+) bool {
+	if !visit(node) {
+		return false
+	}
+	return eachChild(a, node, env, func(n *Node) bool {
+		return walkNodeDescendants(a, n, env, visit)
+	})
+}
+
+func eachChild(a Adapter, node *Node, env *evalEnv, yield nodeYield) bool {
+	switch a.Kind(node.Value) {
+	case NodeArray:
+		for idx := range a.Len(node.Value) {
+			child := node.child(idx, a.Index(node.Value, idx))
+			if !env.enter(child, yield) {
+				return false
+			}
+		}
+	case NodeObject:
+		for _, key := range a.Keys(node.Value) {
+			value, _ := a.Member(node.Value, key)
+			if !env.enter(node.child(key, value), yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func locateName(a Adapter, name string) nodeSelector {
+	return func(node *Node, env *evalEnv, yield nodeYield) bool {
+		if a.Kind(node.Value) != NodeObject {
+			return true
+		}
+		value, ok := a.Member(node.Value, name)
+		if !ok {
+			return true
+		}
+		return env.enter(node.child(name, value), yield)
+	}
+}
+
+func locateIndex(a Adapter, index int) nodeSelector {
+	return func(node *Node, env *evalEnv, yield nodeYield) bool {
+		if a.Kind(node.Value) != NodeArray {
+			return true
+		}
+		size := a.Len(node.Value)
+		pos := normalizeIndex(size, index)
+		if pos >= 0 && pos < size {
+			child := node.child(pos, a.Index(node.Value, pos))
+			return env.enter(child, yield)
+		}
+		return true
+	}
+}
+
+func locateWildcard(a Adapter) nodeSelector {
+	return func(node *Node, env *evalEnv, yield nodeYield) bool {
+		return eachChild(a, node, env, yield)
+	}
+}
+
+func locateSlice(a Adapter, s *SliceExpr) nodeSelector {
+	if s.Step == 0 {
+		return func(*Node, *evalEnv, nodeYield) bool { return true }
+	}
+	return func(node *Node, env *evalEnv, yield nodeYield) bool {
+		if a.Kind(node.Value) != NodeArray {
+			return true
+		}
+		lower, upper := sliceBounds(s, a.Len(node.Value))
+		if s.Step > 0 {
+			for idx := lower; idx < upper; idx += s.Step {
+				child := node.child(idx, a.Index(node.Value, idx))
+				if !env.enter(child, yield) {
+					return false
+				}
+			}
+			return true
+		}
+		for idx := upper; lower < idx; idx += s.Step {
+			child := node.child(idx, a.Index(node.Value, idx))
+			if !env.enter(child, yield) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func locateFilter(a Adapter, filter FilterFunc) nodeSelector {
+	return func(node *Node, env *evalEnv, yield nodeYield) bool {
+		ctx := &FilterCtx{Root: env.root, env: env}
+		return eachChild(a, node, env, func(n *Node) bool {
+			ctx.Current = n.Value
+			ok := env.test(filter, ctx, n)
+			if env.err != nil {
+				return false
+			}
+			return !ok || yield(n)
+		})
+	}
+}
+
+// sliceBounds computes the RFC 9535 iteration bounds of a slice over an
+// array of the given size. With a positive step, indices run upward from
+// lower while below upper. With a negative step, they run downward from
+// upper while above lower
+func sliceBounds(s *SliceExpr, size int) (int, int) {
+	start, end := 0, size
+	if s.Step < 0 {
+		start, end = size-1, -size-1
+	}
+	if s.HasStart {
+		start = normalizeIndex(size, s.Start)
+	}
+	if s.HasEnd {
+		end = normalizeIndex(size, s.End)
+	}
+	if s.Step > 0 {
+		return min(max(start, 0), size), min(max(end, 0), size)
+	}
+	return min(max(end, -1), size-1), min(max(start, -1), size-1)
+}
@@ -0,0 +1,197 @@
+package jpath
+
+import "slices"
+
+// membershipOps are the comparison operators enabled by ExtensionMembership
+var membershipOps = []string{"subsetof", "anyof", "nin", "in"}
+
+// parseMembershipOp consumes a membership operator, if one is enabled
+func (p *Parser) parseMembershipOp() (string, bool) {
+	if !p.Options.extension(ExtensionMembership) {
+		return "", false
+	}
+	for _, op := range membershipOps {
+		if p.consumeKeyword(op) {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// isCompositeStart reports whether the parser is at an array or object
+// literal that ExtensionCompositeLiterals allows
+func (p *Parser) isCompositeStart() bool {
+	if !p.Options.extension(ExtensionCompositeLiterals) {
+		return false
+	}
+	r := p.peek()
+	return r == '[' || r == '{'
+}
+
+func (p *Parser) parseCompositeLiteral() (FilterExpr, error) {
+	start := p.pos
+	v, err := p.parseCompositeValue()
+	if err != nil {
+		return nil, err
+	}
+	return &LiteralExpr{Value: v, Span: p.span(start)}, nil
+}
+
+// parseCompositeValue parses an array or object literal into the []any or
+// map[string]any that encoding/json would decode it to
+func (p *Parser) parseCompositeValue() (any, error) {
+	end := ']'
+	var res any = []any{}
+	if p.peek() == '{' {
+		end = '}'
+		res = map[string]any{}
+	}
+	p.pos++
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	p.skipWS()
+	if p.consume(end) {
+		return res, nil
+	}
+	for {
+		switch r := res.(type) {
+		case []any:
+			v, err := p.parseElementValue()
+			if err != nil {
+				return nil, err
+			}
+			res = append(r, v)
+		case map[string]any:
+			if q := p.peek(); q != '\'' && q != '"' {
+				return nil, wrapPathError(
+					p.text, p.pos, ErrUnexpectedToken, tokenString,
+				)
+			}
+			k, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			p.skipWS()
+			if !p.consume(':') {
+				return nil, wrapPathError(
+					p.text, p.pos, ErrUnexpectedToken, ":",
+				)
+			}
+			v, err := p.parseElementValue()
+			if err != nil {
+				return nil, err
+			}
+			r[k] = v
+		}
+		p.skipWS()
+		if p.consume(end) {
+			return res, nil
+		}
+		if !p.consume(',') {
+			return nil, wrapPathError(
+				p.text, p.pos, ErrUnexpectedToken, string(end), ",",
+			)
+		}
+		p.skipWS()
+	}
+}
+
+// parseElementValue parses a literal within an array or object literal
+func (p *Parser) parseElementValue() (any, error) {
+	p.skipWS()
+	if p.isCompositeStart() {
+		return p.parseCompositeValue()
+	}
+	pos := p.pos
+	ex, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	lit, ok := ex.(*LiteralExpr)
+	if !ok {
+		return nil, wrapPathError(
+			p.text, pos, ErrUnexpectedToken, "[", "{", tokenString,
+			tokenNumber, "true", "false", "null",
+		)
+	}
+	return lit.Value, nil
+}
+
+// valueIn reports whether left is a single value found among the elements
+// of right, which must be a single array
+func valueIn(left, right *Value) bool {
+	found, ok := membership(left, right)
+	return ok && found
+}
+
+// valueNin reports whether left is a single value missing from the
+// elements of right, which must be a single array
+func valueNin(left, right *Value) bool {
+	found, ok := membership(left, right)
+	return ok && !found
+}
+
+// valueSubsetOf reports whether every element of the array left is found
+// among the elements of the array right
+func valueSubsetOf(left, right *Value) bool {
+	l, r, ok := arrayOperands(left, right)
+	if !ok {
+		return false
+	}
+	for _, v := range l {
+		if !containsJSON(r, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueAnyOf reports whether any element of the array left is found among
+// the elements of the array right
+func valueAnyOf(left, right *Value) bool {
+	l, r, ok := arrayOperands(left, right)
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(l, func(v any) bool {
+		return containsJSON(r, v)
+	})
+}
+
+func membership(left, right *Value) (bool, bool) {
+	v, ok := left.singularValue()
+	if !ok || left.IsNothing() {
+		return false, false
+	}
+	arr, ok := arrayOperand(right)
+	if !ok {
+		return false, false
+	}
+	return containsJSON(arr, v), true
+}
+
+func arrayOperands(left, right *Value) ([]any, []any, bool) {
+	l, ok := arrayOperand(left)
+	if !ok {
+		return nil, nil, false
+	}
+	r, ok := arrayOperand(right)
+	return l, r, ok
+}
+
+func arrayOperand(v *Value) ([]any, bool) {
+	raw, ok := v.singularValue()
+	if !ok {
+		return nil, false
+	}
+	arr, ok := raw.([]any)
+	return arr, ok
+}
+
+func containsJSON(arr []any, v any) bool {
+	return slices.ContainsFunc(arr, func(e any) bool {
+		return jsonEqual(e, v)
+	})
+}
@@ -0,0 +1,122 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestMembership(t *testing.T) {
+	doc := map[string]any{
+		"allowed": []any{"open", "blocked"},
+		"items": []any{
+			map[string]any{"status": "open", "tags": []any{"a", "b"}},
+			map[string]any{"status": "closed", "tags": []any{"c"}},
+			map[string]any{"status": "blocked", "tags": []any{}},
+			map[string]any{"tags": "a"},
+		},
+	}
+	items := doc["items"].([]any)
+	cases := map[string][]any{
+		`$.items[?@.status in ['open', 'blocked']]`:  {items[0], items[2]},
+		`$.items[?@.status nin ['open', 'blocked']]`: {items[1]},
+		`$.items[?@.status in $.allowed]`:            {items[0], items[2]},
+		`$.items[?@.status nin $.allowed]`:           {items[1]},
+		`$.items[?@.tags subsetof ['a', 'b', 'x']]`: {
+			items[0], items[2],
+		},
+		`$.items[?@.tags anyof ['b', 'c']]`: {items[0], items[1]},
+		`$.items[?@.tags anyof []]`:         {},
+		`$.items[?'a' in @.tags]`:           {items[0]},
+		`$.items[?@.status in []]`:          {},
+		`$.items[?@.status in 'open']`:      {},
+		`$.items[?@.status nin 'open']`:     {},
+		`$.items[?@.tags == ['a', 'b']]`:    {items[0]},
+		`$.items[?@.tags != ['a', 'b']]`:    items[1:],
+		`$.items[?@.tags == []]`:            {items[2]},
+		`$.items[?@.status in ['open'] && @.tags anyof ['a']]`: {
+			items[0],
+		},
+	}
+	assertQueries(t, extensionRegistry(members), doc, cases)
+}
+
+func TestJSONEquality(t *testing.T) {
+	doc := []any{
+		map[string]any{"a": []any{1, 2.0}},
+		map[string]any{"a": map[string]any{"k": 1, "v": []any{"x"}}},
+		map[string]any{"a": []any{[]any{1}, map[string]any{}}},
+		map[string]any{"a": 2},
+	}
+	cases := map[string][]any{
+		`$[?@.a == [1, 2]]`:                       {doc[0]},
+		`$[?@.a == [2, 1]]`:                       {},
+		`$[?@.a == {'v': ['x'], 'k': 1.0}]`:       {doc[1]},
+		`$[?@.a == {'k': 1}]`:                     {},
+		`$[?@.a == [[1], {}]]`:                    {doc[2]},
+		`$[?@.a == 2.0]`:                          {doc[3]},
+		`$[?@.a in [[1, 2], {'k': 1}, 2]]`:        {doc[0], doc[3]},
+		`$[?@.a <= 2]`:                            {doc[3]},
+		`$[?@.a >= {'k': 1, 'v': ['x']}]`:         {doc[1]},
+		`$[?@.a subsetof [2, 1, 3]]`:              {doc[0]},
+		`$[?@.a anyof [{'a': 1}, {}, 'x']]`:       {doc[2]},
+		`$[?[1, 2] == [1.0, 2.0] && @.a == 2]`:    {doc[3]},
+		`$[?{'a': [1]} != {'a': [1]} || @.a > 1]`: {doc[3]},
+	}
+	assertQueries(t, extensionRegistry(members), doc, cases)
+}
+
+func TestCompositeLiterals(t *testing.T) {
+	ast, err := extensionRegistry(members).Parse(`$[?@.a in [1, {'k': [2]}]]`)
+	if assert.NoError(t, err) {
+		in := ast.Segments[0].Selectors[0].Filter.(*jpath.BinaryExpr)
+		lit := in.Right.(*jpath.LiteralExpr)
+		assert.Equal(t, []any{1.0, map[string]any{"k": []any{2.0}}}, lit.Value)
+	}
+
+	deep := jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		Extensions: composite,
+		MaxDepth:   3,
+	})
+	_, err = deep.Parse(`$[?@.a == [[[1]]]]`)
+	assert.ErrorIs(t, err, jpath.ErrQueryTooDeep)
+}
+
+func TestMembershipLegacyDialect(t *testing.T) {
+	// Native membership replaces the legacy lowering to == and != chains
+	reg := jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		Dialect: jpath.DialectLegacy,
+		Extensions: jpath.ExtensionCompositeLiterals |
+			jpath.ExtensionMembership,
+	})
+	ast, err := reg.Parse(`$[?(@.a in ['S', 'M'])]`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `$[?@.a in ['S', 'M']]`, ast.String())
+	}
+}
+
+func TestMembershipFilterFuncs(t *testing.T) {
+	ctx := &jpath.FilterCtx{}
+	list := jpath.Literal([]any{"a", 1.0})
+	scalar := jpath.Literal(1)
+	nodes := jpath.NodesValue
+	nothing := func(*jpath.FilterCtx) *jpath.Value {
+		return jpath.ScalarValue(jpath.Nothing)
+	}
+	many := func(*jpath.FilterCtx) *jpath.Value {
+		return nodes([]any{"a", "b"})
+	}
+	yes, no := jpath.ScalarValue(true), jpath.ScalarValue(false)
+	assert.Equal(t, yes, jpath.In(scalar, list)(ctx))
+	assert.Equal(t, no, jpath.Nin(scalar, list)(ctx))
+	assert.Equal(t, no, jpath.In(nothing, list)(ctx))
+	assert.Equal(t, no, jpath.Nin(nothing, list)(ctx))
+	assert.Equal(t, no, jpath.In(many, list)(ctx))
+	assert.Equal(t, no, jpath.In(scalar, scalar)(ctx))
+	assert.Equal(t, yes, jpath.SubsetOf(list, list)(ctx))
+	assert.Equal(t, no, jpath.SubsetOf(scalar, list)(ctx))
+	assert.Equal(t, yes, jpath.AnyOf(list, list)(ctx))
+	assert.Equal(t, no, jpath.AnyOf(list, many)(ctx))
+}
@@ -0,0 +1,197 @@
+package jpath
+
+import (
+	"cmp"
+	"slices"
+)
+
+type (
+	// UpdateFunc computes the replacement for a matched value
+	UpdateFunc func(old any) any
+
+	mutationGroup struct {
+		parent *Node
+		keys   []any
+	}
+)
+
+// Set assigns value to every node matched by the query. When the final
+// segment consists only of name selectors, missing members are created on
+// the matched parent objects. It returns the resulting document, which
+// differs from the input only when the root itself is replaced, and the
+// number of nodes that were written
+func (p *NodePath) Set(document, value any) (any, int) {
+	if p.parent == nil {
+		return p.Replace(document, value)
+	}
+	count := 0
+	for _, n := range uniqueNodes(p.locateParents(document)) {
+		obj, ok := n.Value.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, name := range p.names {
+			obj[name] = value
+			count++
+		}
+	}
+	return document, count
+}
+
+// Replace assigns value to every existing node matched by the query. It
+// returns the resulting document and the number of nodes that were written
+func (p *NodePath) Replace(document, value any) (any, int) {
+	return p.Update(document, func(any) any {
+		return value
+	})
+}
+
+// Update replaces every node matched by the query with the result of
+// calling fn on its current value. Nested matches are updated innermost
+// first, so fn sees the already-updated children of a matched container.
+// Only members of map[string]any and []any containers can be written. It
+// returns the resulting document and the number of nodes that were written
+func (p *NodePath) Update(document any, fn UpdateFunc) (any, int) {
+	nodes := uniqueNodes(p.Locate(document))
+	sortDeepestFirst(nodes)
+	count := 0
+	for _, n := range nodes {
+		if n.parent == nil {
+			document = fn(document)
+			count++
+			continue
+		}
+		if setChild(n.parent.Value, n.key, fn(n.Value)) {
+			count++
+		}
+	}
+	return document, count
+}
+
+// Delete removes every node matched by the query. Array elements that share
+// a parent are removed together, so index shifting never affects which
+// elements are deleted. Deleting the root yields a nil document. It returns
+// the resulting document and the number of nodes that were removed
+func (p *NodePath) Delete(document any) (any, int) {
+	count := 0
+	for _, g := range groupByParent(uniqueNodes(p.Locate(document))) {
+		if g.parent == nil {
+			document = nil
+			count++
+			continue
+		}
+		switch v := g.parent.Value.(type) {
+		case map[string]any:
+			for _, key := range g.keys {
+				delete(v, key.(string))
+			}
+			count += len(g.keys)
+		case []any:
+			res := removeIndices(v, g.keys)
+			count += len(v) - len(res)
+			if g.parent.parent == nil {
+				document = res
+				continue
+			}
+			setChild(g.parent.parent.Value, g.parent.key, res)
+		}
+	}
+	return document, count
+}
+
+func (p *NodePath) locateParents(document any) NodeList {
+	res := NodeList{}
+	p.parent(RootNode(document), newEvalEnv(document), func(n *Node) bool {
+		res = append(res, n)
+		return true
+	})
+	return res
+}
+
+func trailingNames(segment *SegmentExpr) []string {
+	if segment.Descendant {
+		return nil
+	}
+	res := make([]string, len(segment.Selectors))
+	for idx, sel := range segment.Selectors {
+		if sel.Kind != SelectorName {
+			return nil
+		}
+		res[idx] = sel.Name
+	}
+	return res
+}
+
+func uniqueNodes(nodes NodeList) NodeList {
+	seen := make(map[string]bool, len(nodes))
+	res := make(NodeList, 0, len(nodes))
+	for _, n := range nodes {
+		path := n.Path()
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		res = append(res, n)
+	}
+	return res
+}
+
+func sortDeepestFirst(nodes NodeList) {
+	slices.SortStableFunc(nodes, func(l, r *Node) int {
+		return cmp.Compare(r.depth(), l.depth())
+	})
+}
+
+func groupByParent(nodes NodeList) []*mutationGroup {
+	var root *mutationGroup
+	var res []*mutationGroup
+	byParent := map[string]*mutationGroup{}
+	for _, n := range nodes {
+		if n.parent == nil {
+			root = &mutationGroup{}
+			continue
+		}
+		path := n.parent.Path()
+		g, ok := byParent[path]
+		if !ok {
+			g = &mutationGroup{parent: n.parent}
+			byParent[path] = g
+			res = append(res, g)
+		}
+		g.keys = append(g.keys, n.key)
+	}
+	slices.SortStableFunc(res, func(l, r *mutationGroup) int {
+		return cmp.Compare(r.parent.depth(), l.parent.depth())
+	})
+	if root != nil {
+		res = append(res, root)
+	}
+	return res
+}
+
+func removeIndices(arr []any, keys []any) []any {
+	drop := make(map[int]bool, len(keys))
+	for _, key := range keys {
+		drop[key.(int)] = true
+	}
+	res := make([]any, 0, len(arr)-len(drop))
+	for idx, elem := range arr {
+		if !drop[idx] {
+			res = append(res, elem)
+		}
+	}
+	return res
+}
+
+func setChild(container, key, value any) bool {
+	switch v := container.(type) {
+	case map[string]any:
+		v[key.(string)] = value
+		return true
+	case []any:
+		v[key.(int)] = value
+		return true
+	default:
+		return false
+	}
+}
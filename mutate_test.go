@@ -0,0 +1,153 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestSetCreatesMembers(t *testing.T) {
+	doc := map[string]any{
+		"items": []any{
+			map[string]any{"id": float64(1)},
+			map[string]any{"id": float64(2), "seen": false},
+			"skip",
+		},
+	}
+
+	res, count, err := jpath.Set("$.items[*].seen", doc, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, map[string]any{
+		"items": []any{
+			map[string]any{"id": float64(1), "seen": true},
+			map[string]any{"id": float64(2), "seen": true},
+			"skip",
+		},
+	}, res)
+}
+
+func TestSetReplacesMatches(t *testing.T) {
+	doc := []any{float64(1), float64(2), float64(3)}
+	res, count, err := jpath.Set("$[?@ > 1]", doc, float64(0))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []any{float64(1), float64(0), float64(0)}, res)
+
+	res, count, err = jpath.Set("$", doc, "root")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "root", res)
+}
+
+func TestReplaceSkipsMissing(t *testing.T) {
+	doc := map[string]any{"a": float64(1)}
+	res, count, err := jpath.Replace("$['a','b']", doc, "x")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, map[string]any{"a": "x"}, res)
+}
+
+func TestUpdateInnermostFirst(t *testing.T) {
+	doc := map[string]any{
+		"a": map[string]any{"b": float64(1)},
+	}
+	var seen []any
+	res, count, err := jpath.Update("$..*", doc, func(old any) any {
+		seen = append(seen, old)
+		if n, ok := old.(float64); ok {
+			return n + 1
+		}
+		return old
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []any{
+		float64(1), map[string]any{"b": float64(2)},
+	}, seen)
+	assert.Equal(t, map[string]any{
+		"a": map[string]any{"b": float64(2)},
+	}, res)
+}
+
+func TestDeleteSharedParent(t *testing.T) {
+	doc := map[string]any{
+		"list": []any{
+			float64(0), float64(1), float64(2), float64(3), float64(4),
+		},
+		"keep": "yes",
+		"drop": "no",
+	}
+
+	res, count, err := jpath.Delete("$['list'][1,3,-1,1]", doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []any{float64(0), float64(2)}, res.(map[string]any)["list"])
+
+	res, count, err = jpath.Delete("$.drop", res)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, map[string]any{
+		"list": []any{float64(0), float64(2)},
+		"keep": "yes",
+	}, res)
+}
+
+func TestDeleteNested(t *testing.T) {
+	doc := []any{
+		[]any{float64(1), float64(2), float64(1)},
+		float64(1),
+		[]any{float64(3)},
+	}
+
+	res, count, err := jpath.Delete("$..[?@ == 1]", doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []any{
+		[]any{float64(2)},
+		[]any{float64(3)},
+	}, res)
+
+	res, count, err = jpath.Delete("$", res)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1, count)
+	assert.Nil(t, res)
+}
+
+func TestMutationErrors(t *testing.T) {
+	doc := map[string]any{}
+	reg := jpath.NewRegistry()
+
+	res, _, err := reg.Set("", doc, nil)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+	assert.Equal(t, doc, res)
+
+	_, _, err = reg.Replace("$[?missing()]", doc, nil)
+	assert.ErrorIs(t, err, jpath.ErrUnknownFunc)
+
+	_, _, err = reg.Update("$[", doc, nil)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+
+	_, _, err = reg.Delete("$[", doc)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+}
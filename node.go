@@ -0,0 +1,129 @@
+package jpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Location is an RFC 9535 normalized path. Each element is either a
+	// member name (string) or a non-negative array index (int)
+	Location []any
+
+	// Node is a matched value along with its position in the document
+	Node struct {
+		Value  any
+		parent *Node
+		key    any
+		level  int
+	}
+
+	// NodeList is an ordered list of matched nodes
+	NodeList []*Node
+)
+
+// RootNode wraps a document as the root node of a query
+func RootNode(document any) *Node {
+	return &Node{Value: document}
+}
+
+// Location returns the normalized path of this node
+func (n *Node) Location() Location {
+	depth := n.depth()
+	res := make(Location, depth)
+	for c := n; c.parent != nil; c = c.parent {
+		depth--
+		res[depth] = c.key
+	}
+	return res
+}
+
+// Path returns the normalized path of this node in query syntax
+func (n *Node) Path() string {
+	return n.Location().String()
+}
+
+func (n *Node) depth() int {
+	return n.level
+}
+
+func (n *Node) child(key, value any) *Node {
+	return &Node{Value: value, parent: n, key: key, level: n.level + 1}
+}
+
+// Values returns the matched values in node-list order
+func (l NodeList) Values() []any {
+	res := make([]any, len(l))
+	for idx, n := range l {
+		res[idx] = n.Value
+	}
+	return res
+}
+
+// Locations returns the normalized paths in node-list order
+func (l NodeList) Locations() []Location {
+	res := make([]Location, len(l))
+	for idx, n := range l {
+		res[idx] = n.Location()
+	}
+	return res
+}
+
+// Paths returns the normalized paths in query syntax, in node-list order
+func (l NodeList) Paths() []string {
+	res := make([]string, len(l))
+	for idx, n := range l {
+		res[idx] = n.Path()
+	}
+	return res
+}
+
+// String renders the location as a normalized path, such as
+// $['store']['book'][0]
+func (l Location) String() string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, elem := range l {
+		switch v := elem.(type) {
+		case string:
+			b.WriteString("['")
+			writeNormalizedName(&b, v)
+			b.WriteString("']")
+		case int:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(v))
+			b.WriteByte(']')
+		default:
+			panic(fmt.Sprintf("invalid location element: %v", elem))
+		}
+	}
+	return b.String()
+}
+
+func writeNormalizedName(b *strings.Builder, name string) {
+	for _, r := range name {
+		switch r {
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestLocateStore(t *testing.T) {
+	doc := map[string]any{
+		"store": map[string]any{
+			"book": []any{
+				map[string]any{"title": "a", "price": float64(8)},
+				map[string]any{"title": "b", "price": float64(12)},
+			},
+		},
+	}
+
+	got, err := jpath.Locate("$.store.book[?@.price > 10].title", doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []any{"b"}, got.Values())
+	assert.Equal(t, []string{"$['store']['book'][1]['title']"}, got.Paths())
+	assert.Equal(t, []jpath.Location{
+		{"store", "book", 1, "title"},
+	}, got.Locations())
+}
+
+func TestLocateDescendants(t *testing.T) {
+	doc := map[string]any{
+		"a": []any{float64(1), map[string]any{"b": float64(2)}},
+		"b": float64(3),
+	}
+
+	got := jpath.MustLocate("$..b", doc)
+	assert.Equal(t, []string{"$['b']", "$['a'][1]['b']"}, got.Paths())
+
+	got = jpath.MustLocate("$.a[-1:0:-1]", doc)
+	assert.Equal(t, []string{"$['a'][1]"}, got.Paths())
+
+	got = jpath.MustLocate("$", doc)
+	assert.Equal(t, []string{"$"}, got.Paths())
+}
+
+func TestLocationString(t *testing.T) {
+	loc := jpath.Location{"it's", "a\\b", "\n\t\u0001", 3}
+	assert.Equal(t, `$['it\'s']['a\\b']['\n\t\u0001'][3]`, loc.String())
+	assert.Equal(t, "$", jpath.RootNode(nil).Path())
+}
+
+func TestCompileNodes(t *testing.T) {
+	reg := jpath.NewRegistry()
+	path := reg.MustCompileNodes(reg.MustParse("$[*]"))
+	got := path.Locate([]any{"x", "y"})
+	assert.Equal(t, []any{"x", "y"}, got.Values())
+	assert.Equal(t, []string{"$[0]", "$[1]"}, got.Paths())
+
+	path = jpath.MustCompileNodes(jpath.MustParse("$[::0]"))
+	assert.Empty(t, path.Locate([]any{"x"}))
+
+	assert.Panics(t, func() {
+		_ = jpath.MustLocate("$[?1]", nil)
+	})
+	_, err := jpath.CompileNodes(&jpath.PathExpr{
+		Segments: []*jpath.SegmentExpr{{
+			Selectors: []*jpath.SelectorExpr{{Kind: 99}},
+		}},
+	})
+	assert.Error(t, err)
+}
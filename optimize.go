@@ -0,0 +1,265 @@
+package jpath
+
+// Optimize returns a simplified copy of a valid path that selects the same
+// nodes. Comparisons between literals are folded, double negations are
+// removed, logical operators with a constant operand are reduced, filters
+// that are always true become wildcards, and slices that take a whole
+// array are reduced to the form that compiles to a plain array copy. A
+// wildcard is left alone, since on an array it already appends the whole
+// array at once. The path passed in is left unchanged, and the result is
+// itself a valid path. Compile applies Optimize itself, so it only needs to
+// be called to inspect the result
+func Optimize(path *PathExpr) *PathExpr {
+	res := &PathExpr{
+		Segments: make([]*SegmentExpr, len(path.Segments)),
+		Span:     path.Span,
+	}
+	for idx, sg := range path.Segments {
+		res.Segments[idx] = optimizeSegment(sg)
+	}
+	return res
+}
+
+func optimizeSegment(sg *SegmentExpr) *SegmentExpr {
+	res := &SegmentExpr{
+		Descendant: sg.Descendant,
+		Selectors:  make([]*SelectorExpr, 0, len(sg.Selectors)),
+		Span:       sg.Span,
+	}
+	var never *SelectorExpr
+	for _, sel := range sg.Selectors {
+		sel = optimizeSelector(sel)
+		if isNever(sel) {
+			never = sel
+			continue
+		}
+		res.Selectors = append(res.Selectors, sel)
+	}
+	if len(res.Selectors) == 0 && never != nil {
+		// a segment needs a selector, even one that matches nothing
+		res.Selectors = append(res.Selectors, emptySelector(never.Span))
+	}
+	return res
+}
+
+// emptySelector returns a selector that matches nothing. A bare false
+// can't stand as a filter, so it is the empty slice [0:0]
+func emptySelector(span Span) *SelectorExpr {
+	return &SelectorExpr{
+		Kind: SelectorSlice,
+		Slice: &SliceExpr{
+			HasStart: true,
+			HasEnd:   true,
+			Step:     1,
+		},
+		Span: span,
+	}
+}
+
+func optimizeSelector(sel *SelectorExpr) *SelectorExpr {
+	switch sel.Kind {
+	case SelectorSlice:
+		return &SelectorExpr{
+			Kind:  SelectorSlice,
+			Slice: optimizeSlice(sel.Slice),
+			Span:  sel.Span,
+		}
+	case SelectorFilter:
+		filter := optimizeFilter(sel.Filter)
+		if b, ok := constantBool(filter); ok && b {
+			return &SelectorExpr{Kind: SelectorWildcard, Span: sel.Span}
+		}
+		return &SelectorExpr{
+			Kind:   SelectorFilter,
+			Filter: filter,
+			Span:   sel.Span,
+		}
+	default:
+		res := *sel
+		return &res
+	}
+}
+
+// optimizeSlice reduces a slice that takes every element of an array in
+// order, such as [0:] or [::1], to [:]
+func optimizeSlice(s *SliceExpr) *SliceExpr {
+	res := *s
+	if res.Step == 1 && !res.HasEnd && (!res.HasStart || res.Start == 0) {
+		res.HasStart = false
+		res.Start = 0
+	}
+	return &res
+}
+
+func optimizeFilter(expr FilterExpr) FilterExpr {
+	switch v := expr.(type) {
+	case *PathValueExpr:
+		return &PathValueExpr{
+			Absolute: v.Absolute,
+			Path:     Optimize(v.Path),
+			Span:     v.Span,
+		}
+	case *UnaryExpr:
+		return optimizeUnary(v)
+	case *BinaryExpr:
+		return optimizeBinary(v)
+	case *ArithmeticExpr:
+		return optimizeArithmetic(v)
+	case *NegateExpr:
+		inner := optimizeFilter(v.Expr)
+		if n, ok := numberLiteral(inner); ok {
+			return &LiteralExpr{Value: -n, Span: v.Span}
+		}
+		return &NegateExpr{Expr: inner, Span: v.Span}
+	case *FuncExpr:
+		args := make([]FilterExpr, len(v.Args))
+		for idx, arg := range v.Args {
+			args[idx] = optimizeArg(arg)
+		}
+		return &FuncExpr{Name: v.Name, Args: args, Span: v.Span}
+	default:
+		return expr
+	}
+}
+
+// optimizeArg optimizes a function argument. A logical argument that folds
+// to a constant is kept as written, since true or false can't be passed
+// where a logical argument is expected
+func optimizeArg(arg FilterExpr) FilterExpr {
+	res := optimizeFilter(arg)
+	if _, ok := constantBool(res); ok {
+		return arg
+	}
+	return res
+}
+
+func optimizeUnary(v *UnaryExpr) FilterExpr {
+	inner := optimizeFilter(v.Expr)
+	if v.Op != "!" {
+		return &UnaryExpr{Op: v.Op, Expr: inner, Span: v.Span}
+	}
+	if b, ok := constantBool(inner); ok {
+		return &LiteralExpr{Value: !b, Span: v.Span}
+	}
+	if u, ok := inner.(*UnaryExpr); ok && u.Op == "!" {
+		return u.Expr
+	}
+	return &UnaryExpr{Op: v.Op, Expr: inner, Span: v.Span}
+}
+
+// optimizeArithmetic folds arithmetic between number literals, unless
+// the result is Nothing
+func optimizeArithmetic(v *ArithmeticExpr) FilterExpr {
+	left := optimizeFilter(v.Left)
+	right := optimizeFilter(v.Right)
+	res := &ArithmeticExpr{Op: v.Op, Left: left, Right: right, Span: v.Span}
+	build, ok := arithmeticOps[v.Op]
+	if !ok {
+		return res
+	}
+	if _, ok := numberLiteral(left); !ok {
+		return res
+	}
+	if _, ok := numberLiteral(right); !ok {
+		return res
+	}
+	l := Literal(left.(*LiteralExpr).Value)
+	r := Literal(right.(*LiteralExpr).Value)
+	folded := build(l, r)(&FilterCtx{})
+	if folded.IsNothing() {
+		return res
+	}
+	return &LiteralExpr{Value: folded.Scalar, Span: v.Span}
+}
+
+func optimizeBinary(v *BinaryExpr) FilterExpr {
+	left := optimizeFilter(v.Left)
+	right := optimizeFilter(v.Right)
+	switch v.Op {
+	case "&&":
+		return reduceLogical(v, left, right, false)
+	case "||":
+		return reduceLogical(v, left, right, true)
+	}
+	if res, ok := foldComparison(v.Op, left, right); ok {
+		return &LiteralExpr{Value: res, Span: v.Span}
+	}
+	return &BinaryExpr{Op: v.Op, Left: left, Right: right, Span: v.Span}
+}
+
+// reduceLogical simplifies && (when absorbing is false) or || (when it is
+// true). An operand equal to absorbing decides the result on its own, and
+// an operand equal to its opposite can be dropped
+func reduceLogical(
+	v *BinaryExpr, left, right FilterExpr, absorbing bool,
+) FilterExpr {
+	if b, ok := constantBool(left); ok {
+		if b == absorbing {
+			return left
+		}
+		return right
+	}
+	if b, ok := constantBool(right); ok {
+		if b == absorbing {
+			return right
+		}
+		return left
+	}
+	return &BinaryExpr{Op: v.Op, Left: left, Right: right, Span: v.Span}
+}
+
+func foldComparison(op string, left, right FilterExpr) (bool, bool) {
+	l, ok := left.(*LiteralExpr)
+	if !ok {
+		return false, false
+	}
+	r, ok := right.(*LiteralExpr)
+	if !ok {
+		return false, false
+	}
+	lv, rv := ScalarValue(l.Value), ScalarValue(r.Value)
+	switch op {
+	case "==":
+		return compareValuesEq(lv, rv), true
+	case "!=":
+		return compareValuesNe(lv, rv), true
+	case "<":
+		return compareValuesLt(lv, rv), true
+	case "<=":
+		return compareValuesLe(lv, rv), true
+	case ">":
+		return compareValuesGt(lv, rv), true
+	case ">=":
+		return compareValuesGe(lv, rv), true
+	case "in":
+		return valueIn(lv, rv), true
+	case "nin":
+		return valueNin(lv, rv), true
+	case "subsetof":
+		return valueSubsetOf(lv, rv), true
+	case "anyof":
+		return valueAnyOf(lv, rv), true
+	default:
+		return false, false
+	}
+}
+
+// constantBool reports the value of a filter expression folded down to a
+// boolean literal. Only folding produces these, since the grammar requires
+// literals to be compared
+func constantBool(expr FilterExpr) (bool, bool) {
+	l, ok := expr.(*LiteralExpr)
+	if !ok {
+		return false, false
+	}
+	b, ok := l.Value.(bool)
+	return b, ok
+}
+
+func isNever(sel *SelectorExpr) bool {
+	if sel.Kind != SelectorFilter {
+		return false
+	}
+	b, ok := constantBool(sel.Filter)
+	return ok && !b
+}
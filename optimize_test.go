@@ -0,0 +1,97 @@
+package jpath_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestOptimize(t *testing.T) {
+	cases := map[string]string{
+		`$.a[*]`:                       `$.a[*]`,
+		`$[?1 == 1]`:                   `$[*]`,
+		`$[?1 == 2]`:                   `$[0:0]`,
+		`$[?'a' < 'b' && @.x]`:         `$[?@.x]`,
+		`$[?@.x && 1 > 2]`:             `$[0:0]`,
+		`$[?@.x || null == null]`:      `$[*]`,
+		`$[?@.x || 1 != 1]`:            `$[?@.x]`,
+		`$[?!!@.x]`:                    `$[?@.x]`,
+		`$[?!!!@.x]`:                   `$[?!@.x]`,
+		`$[?!(1 == 2)]`:                `$[*]`,
+		`$[?@[?2 >= 3], 'y']`:          `$[?@[0:0], 'y']`,
+		`$[?1 == 2, 'y']`:              `$.y`,
+		`$[0:]`:                        `$[:]`,
+		`$[::1]`:                       `$[:]`,
+		`$[1:]`:                        `$[1:]`,
+		`$[0::2]`:                      `$[0::2]`,
+		`$[?length(@.a) == 2 * 1]`:     ``,
+		`$[?count(@.*) > 1 && 2 == 2]`: `$[?count(@[*]) > 1]`,
+		`$[?count($[?1 == 1]) > 1]`:    `$[?count($[*]) > 1]`,
+	}
+	for query, want := range cases {
+		ast, err := jpath.Parse(query)
+		if want == "" {
+			assert.Error(t, err, query)
+			continue
+		}
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+		before := ast.String()
+		opt := jpath.Optimize(ast)
+		assert.Equal(t, want, opt.String(), query)
+		assert.Equal(t, before, ast.String(), query)
+		_, err = jpath.Compile(opt)
+		assert.NoError(t, err, query)
+		_, err = jpath.Parse(opt.String())
+		assert.NoError(t, err, query)
+	}
+}
+
+func TestOptimizedQueries(t *testing.T) {
+	doc := map[string]any{
+		"a": []any{1.0, 2.0, 3.0},
+		"c": []any{
+			map[string]any{"x": 1.0},
+			map[string]any{"y": 2.0},
+		},
+	}
+	cases := map[string][]any{
+		`$.a[0:]`:                     {1.0, 2.0, 3.0},
+		`$.a[?1 == 1]`:                {1.0, 2.0, 3.0},
+		`$.a[?1 == 2]`:                {},
+		`$.a[?1 == 2, 0]`:             {1.0},
+		`$.c[?!!@.x]`:                 {map[string]any{"x": 1.0}},
+		`$.c[?@.y || 1 == 2]`:         {map[string]any{"y": 2.0}},
+		`$.a[?true == true && @ > 1]`: {2.0, 3.0},
+	}
+	for query, want := range cases {
+		res, err := jpath.Query(query, doc)
+		if assert.NoError(t, err, query) {
+			assert.Equal(t, want, res, query)
+		}
+		nodes, err := jpath.Locate(query, doc)
+		if assert.NoError(t, err, query) {
+			assert.Equal(t, want, nodes.Values(), query)
+		}
+	}
+}
+
+func TestHoistRootSubquery(t *testing.T) {
+	items := make([]any, 100)
+	for idx := range items {
+		items[idx] = float64(idx)
+	}
+	doc := map[string]any{"x": 42.0, "items": items}
+
+	// $.x is evaluated once for the array rather than once per element
+	res, err := jpath.QueryContext(
+		context.Background(), `$.items[?@ == $.x]`, doc,
+		jpath.EvalOptions{MaxVisited: 110},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{42.0}, res)
+}
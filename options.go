@@ -0,0 +1,143 @@
+package jpath
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+)
+
+type (
+	// ParserOptions controls the grammar a Parser accepts. The zero value
+	// accepts RFC 9535 queries of any length and depth, along with the
+	// top-level filter shorthand, and skips any Unicode white space
+	ParserOptions struct {
+		// Strict accepts only RFC 9535 syntax. It rejects the top-level
+		// filter shorthand, ignores Dialect and Extensions, and only skips
+		// the white space RFC 9535 allows
+		Strict bool
+
+		// Dialect selects the query syntax accepted
+		Dialect Dialect
+
+		// Extensions enables syntax beyond RFC 9535
+		Extensions Extension
+
+		// MaxLength limits the length of a query, in runes
+		MaxLength int
+
+		// MaxDepth limits how deeply brackets and parentheses may nest
+		MaxDepth int
+
+		// Whitespace selects the characters skipped between tokens
+		Whitespace Whitespace
+	}
+
+	// Extension is a set of optional syntaxes that a Parser accepts only
+	// when enabled in ParserOptions
+	Extension uint
+
+	// Whitespace selects the characters a Parser skips between tokens
+	Whitespace uint8
+)
+
+const (
+	// ExtensionArithmetic enables the arithmetic operators +, -, *, /,
+	// and %, and unary minus, in filter comparisons
+	ExtensionArithmetic Extension = 1 << iota
+
+	// ExtensionCompositeLiterals enables array and object literals, such
+	// as ['a', 'b'] and {'k': 1}, in filter comparisons
+	ExtensionCompositeLiterals
+
+	// ExtensionMembership enables the in, nin, subsetof, and anyof
+	// comparison operators
+	ExtensionMembership
+
+	// ExtensionRegexMatch enables the =~ comparison operator, which tests
+	// whether a string matches a pattern in its entirety, like match()
+	ExtensionRegexMatch
+)
+
+const (
+	WhitespaceUnicode Whitespace = iota // any Unicode white space
+	WhitespaceRFC                       // space, tab, line feed, return
+	WhitespaceNone                      // no white space at all
+)
+
+var (
+	// ErrQueryTooLong indicates a query is longer than MaxLength
+	ErrQueryTooLong = errors.New("query too long")
+
+	// ErrQueryTooDeep indicates a query nests deeper than MaxDepth
+	ErrQueryTooDeep = errors.New("query nested too deeply")
+)
+
+// WithParserOptions returns a copy of this registry that parses queries
+// with the provided options
+func (r *Registry) WithParserOptions(opts ParserOptions) *Registry {
+	res := r.Clone()
+	res.parser = opts
+	return res
+}
+
+// ParserOptions returns the options this registry parses queries with
+func (r *Registry) ParserOptions() ParserOptions {
+	return r.parser
+}
+
+// Has reports whether every extension in ext is enabled
+func (e Extension) Has(ext Extension) bool {
+	return e&ext == ext
+}
+
+func (o ParserOptions) legacy() bool {
+	return !o.Strict && o.Dialect == DialectLegacy
+}
+
+func (o ParserOptions) extension(ext Extension) bool {
+	return !o.Strict && o.Extensions.Has(ext)
+}
+
+func (o ParserOptions) isSpace(r rune) bool {
+	ws := o.Whitespace
+	if o.Strict {
+		ws = WhitespaceRFC
+	}
+	switch ws {
+	case WhitespaceRFC:
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	case WhitespaceNone:
+		return false
+	default:
+		return unicode.IsSpace(r)
+	}
+}
+
+func (o ParserOptions) checkLength(query string, runes int) error {
+	if o.MaxLength <= 0 || runes <= o.MaxLength {
+		return nil
+	}
+	return wrapPathError(
+		query, o.MaxLength,
+		fmt.Errorf("%w: limit is %d runes", ErrQueryTooLong, o.MaxLength),
+	)
+}
+
+// enter records that the parser has just consumed an opening bracket or
+// parenthesis, failing when it nests deeper than MaxDepth. A successful
+// call must be paired with a call to leave
+func (p *Parser) enter() error {
+	p.depth++
+	if limit := p.Options.MaxDepth; limit > 0 && p.depth > limit {
+		p.depth--
+		return wrapPathError(
+			p.text, p.pos-1,
+			fmt.Errorf("%w: limit is %d", ErrQueryTooDeep, limit),
+		)
+	}
+	return nil
+}
+
+func (p *Parser) leave() {
+	p.depth--
+}
@@ -0,0 +1,144 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestParserOptionsStrict(t *testing.T) {
+	reg := jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		Strict:  true,
+		Dialect: jpath.DialectLegacy,
+	})
+	cases := map[string]error{
+		`@.a`:                    jpath.ErrExpectedRoot,
+		`!@.a`:                   jpath.ErrExpectedRoot,
+		`$.a == 1`:               jpath.ErrUnexpectedToken,
+		`$[book]`:                jpath.ErrUnexpectedToken,
+		"$[?@.a ==\u00a01]":      jpath.ErrUnexpectedToken,
+		"$[?@.a ==\u3000\t1]":    jpath.ErrUnexpectedToken,
+		`$[?(@.a in ['x'])]`:     jpath.ErrUnexpectedToken,
+		`$[?@.tags.length > 1]x`: jpath.ErrUnexpectedToken,
+	}
+	for query, want := range cases {
+		_, err := reg.Parse(query)
+		assert.ErrorIs(t, err, want, query)
+		_, err = jpath.Parse(query)
+		if want == jpath.ErrExpectedRoot {
+			assert.NoError(t, err, query)
+		}
+	}
+
+	for _, query := range []string{
+		`$`,
+		"$[?@.a ==\t\r\n 1]",
+		`$.a[?@.b == 1 && length(@.c) > 2]`,
+		`$..*`,
+	} {
+		_, err := reg.Parse(query)
+		assert.NoError(t, err, query)
+	}
+}
+
+func TestParserOptionsWhitespace(t *testing.T) {
+	rfc := jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		Whitespace: jpath.WhitespaceRFC,
+	})
+	none := jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		Whitespace: jpath.WhitespaceNone,
+	})
+
+	_, err := jpath.Parse("$[1, 2]")
+	assert.NoError(t, err)
+	_, err = rfc.Parse("$[1, 2]")
+	assert.ErrorIs(t, err, jpath.ErrUnexpectedToken)
+	_, err = rfc.Parse("$[1,\t2]")
+	assert.NoError(t, err)
+
+	_, err = none.Parse("$[1,2]")
+	assert.NoError(t, err)
+	_, err = none.Parse("$[1, 2]")
+	assert.ErrorIs(t, err, jpath.ErrUnexpectedToken)
+}
+
+func TestParserOptionsMaxLength(t *testing.T) {
+	reg := jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		MaxLength: 5,
+	})
+	_, err := reg.Parse(`$.é.b`)
+	assert.NoError(t, err)
+
+	_, err = reg.Query(`$.é.bc`, nil)
+	assert.ErrorIs(t, err, jpath.ErrQueryTooLong)
+	qe := queryError(t, err)
+	assert.Equal(t, jpath.ErrQueryTooLong, qe.Code)
+	assert.Equal(t, 5, qe.RuneOffset)
+	assert.Equal(t, 6, qe.Offset)
+	assert.Contains(t, qe.Error(), "limit is 5 runes")
+}
+
+func TestParserOptionsMaxDepth(t *testing.T) {
+	reg := jpath.NewRegistry().WithParserOptions(jpath.ParserOptions{
+		MaxDepth: 3,
+	})
+	for _, query := range []string{
+		`$[?((@.a))]`,
+		`$[?@[?@[0]]]`,
+		`$[?length(@[0]) > 1]`,
+		`$[0][1][2][3][4]`,
+		`(@.a) && (@.b)`,
+	} {
+		_, err := reg.Parse(query)
+		assert.NoError(t, err, query)
+	}
+
+	cases := map[string]int{
+		`$[?(((@.a)))]`:           5,
+		`$[?@[?@[?@[0]]]]`:        10,
+		`$[?length(@[?@[0]]) >1]`: 14,
+		`$[?((length(@)))]`:       11,
+	}
+	for query, offset := range cases {
+		_, err := reg.Parse(query)
+		assert.ErrorIs(t, err, jpath.ErrQueryTooDeep, query)
+		assert.Equal(t, offset, queryError(t, err).RuneOffset, query)
+	}
+
+	// Depth is tracked correctly across recovered errors
+	_, err := reg.ParseRecover(`$[?(@.a &&), ?((@.b))][?((@.c))]`)
+	var errs jpath.QueryErrors
+	if assert.ErrorAs(t, err, &errs) {
+		assert.Len(t, errs, 1)
+	}
+}
+
+func TestParserOptionsRegistry(t *testing.T) {
+	opts := jpath.ParserOptions{
+		Dialect:   jpath.DialectLegacy,
+		MaxLength: 64,
+	}
+	reg := jpath.NewRegistry().WithCache(4).WithParserOptions(opts)
+	assert.Equal(t, opts, reg.ParserOptions())
+	assert.Equal(t, opts, reg.Clone().ParserOptions())
+	assert.Equal(t, opts, reg.WithReflection().ParserOptions())
+	assert.Equal(t, jpath.ParserOptions{}, jpath.NewRegistry().ParserOptions())
+
+	doc := map[string]any{"book": []any{"a", "b"}}
+	res, err := reg.Query(`$[book][(@.length-1)]`, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"b"}, res)
+
+	_, err = jpath.Query(`$[book]`, doc)
+	assert.ErrorIs(t, err, jpath.ErrUnexpectedToken)
+}
+
+func TestExtensionHas(t *testing.T) {
+	ext := jpath.Extension(0b101)
+	assert.True(t, ext.Has(0b001))
+	assert.True(t, ext.Has(0b101))
+	assert.False(t, ext.Has(0b010))
+	assert.True(t, ext.Has(0))
+}
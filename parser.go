@@ -2,7 +2,6 @@ package jpath
 
 import (
 	"errors"
-	"fmt"
 	"math"
 	"strconv"
 	"strings"
@@ -12,9 +11,17 @@ import (
 
 // Parser parses JSONPath query strings into an inspectable syntax tree
 type Parser struct {
-	src  []rune
-	text string
-	pos  int
+	// Options controls the grammar accepted
+	Options ParserOptions
+
+	src      []rune
+	text     string
+	pos      int
+	depth    int
+	rewrites []Rewrite
+
+	recovering bool
+	errs       QueryErrors
 }
 
 var (
@@ -48,22 +55,74 @@ const maxJSONInt = int64(9007199254740991)
 // Parse parses a JSONPath query into a PathExpr syntax tree
 func (p *Parser) Parse(query string) (*PathExpr, error) {
 	if query == "" || strings.TrimSpace(query) != query {
-		return nil, wrapPathError(query, 0, ErrExpectedRoot)
+		return nil, wrapPathError(query, 0, ErrExpectedRoot, "$")
 	}
 	p.src = []rune(query)
 	p.text = query
 	p.pos = 0
+	p.depth = 0
+	p.rewrites = nil
+	if err := p.Options.checkLength(query, len(p.src)); err != nil {
+		return nil, err
+	}
+	if p.Options.Strict {
+		return p.parseQuery()
+	}
 
 	expr, err := p.parseFilter()
 	if err != nil {
 		return nil, err
 	}
 	if !p.eof() {
-		return nil, wrapPathError(query, p.pos, ErrUnexpectedToken)
+		err := wrapPathError(query, p.pos, ErrUnexpectedToken, tokenEnd)
+		if !p.recovered(err) {
+			return nil, err
+		}
 	}
 	return topLevelPathOrFilter(expr), nil
 }
 
+// ParseRecover parses a query like Parse, but rather than stopping at the
+// first syntax error it records the error and resumes at the next ',',
+// ']', or ')' that ends the selector or argument holding it. It returns
+// as much of the syntax tree as could be parsed, along with a QueryErrors
+// listing every error found, or a nil error if there were none
+func (p *Parser) ParseRecover(query string) (*PathExpr, error) {
+	p.recovering = true
+	p.errs = nil
+	defer func() {
+		p.recovering = false
+		p.errs = nil
+	}()
+	path, err := p.Parse(query)
+	if err != nil {
+		p.recovered(err)
+	}
+	if len(p.errs) == 0 {
+		return path, nil
+	}
+	return path, p.errs
+}
+
+// parseQuery parses a query that must be a path starting at the root,
+// without the top-level filter shorthand
+func (p *Parser) parseQuery() (*PathExpr, error) {
+	if !p.consume('$') {
+		return nil, wrapPathError(p.text, p.pos, ErrExpectedRoot, "$")
+	}
+	path, err := p.parseRelativePath(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		err := wrapPathError(p.text, p.pos, ErrUnexpectedToken, tokenEnd)
+		if !p.recovered(err) {
+			return nil, err
+		}
+	}
+	return path, nil
+}
+
 func topLevelPathOrFilter(expr FilterExpr) *PathExpr {
 	if path, ok := expr.(*PathValueExpr); ok && path.Absolute {
 		return path.Path
@@ -72,6 +131,7 @@ func topLevelPathOrFilter(expr FilterExpr) *PathExpr {
 }
 
 func topLevelFilterPath(filter FilterExpr) *PathExpr {
+	sp := filter.span()
 	return &PathExpr{
 		Segments: []*SegmentExpr{
 			{
@@ -79,33 +139,47 @@ func topLevelFilterPath(filter FilterExpr) *PathExpr {
 					{
 						Kind:   SelectorFilter,
 						Filter: filter,
+						Span:   sp,
 					},
 				},
+				Span: sp,
 			},
 		},
+		Span: sp,
 	}
 }
 
-func (p *Parser) parseRelativePath() (*PathExpr, error) {
+// parseRelativePath parses the segments following a root or current node
+// identifier that began at start
+func (p *Parser) parseRelativePath(start int) (*PathExpr, error) {
 	var segments []*SegmentExpr
+	end := p.pos
 	for {
 		p.skipWS()
 		sg, ok, err := p.parseSegment()
 		if err != nil {
-			return nil, err
+			if !p.recovered(err) {
+				return nil, err
+			}
+			break
 		}
 		if !ok {
 			break
 		}
 		segments = append(segments, sg)
+		end = p.pos
 	}
-	return &PathExpr{Segments: segments}, nil
+	return &PathExpr{
+		Segments: segments,
+		Span:     Span{Start: start, End: end},
+	}, nil
 }
 
 func (p *Parser) parseSegment() (*SegmentExpr, bool, error) {
 	if p.eof() {
 		return nil, false, nil
 	}
+	start := p.pos
 	if p.peek() == '.' {
 		p.pos++
 		if p.consume('.') {
@@ -116,6 +190,7 @@ func (p *Parser) parseSegment() (*SegmentExpr, bool, error) {
 			return &SegmentExpr{
 				Descendant: true,
 				Selectors:  sels,
+				Span:       p.span(start),
 			}, true, nil
 		}
 		sel, err := p.parseDotSelector()
@@ -124,6 +199,7 @@ func (p *Parser) parseSegment() (*SegmentExpr, bool, error) {
 		}
 		return &SegmentExpr{
 			Selectors: []*SelectorExpr{sel},
+			Span:      p.span(start),
 		}, true, nil
 	}
 	if p.peek() == '[' {
@@ -133,6 +209,7 @@ func (p *Parser) parseSegment() (*SegmentExpr, bool, error) {
 		}
 		return &SegmentExpr{
 			Selectors: sels,
+			Span:      p.span(start),
 		}, true, nil
 	}
 	return nil, false, nil
@@ -140,81 +217,92 @@ func (p *Parser) parseSegment() (*SegmentExpr, bool, error) {
 
 func (p *Parser) parseDescendantSelectors() ([]*SelectorExpr, error) {
 	if p.eof() {
-		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
-	}
-	if p.peek() == '*' {
-		p.pos++
-		return []*SelectorExpr{
-			{Kind: SelectorWildcard},
-		}, nil
+		return nil, wrapPathError(
+			p.text, p.pos, ErrUnexpectedToken, tokenName, "*", "[",
+		)
 	}
 	if p.peek() == '[' {
 		return p.parseBracketSelectors()
 	}
-	nm, ok := p.parseMemberName()
-	if !ok {
-		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+	sel, err := p.parseDotSelector()
+	if err != nil {
+		return nil, err
 	}
-	return []*SelectorExpr{
-		{Kind: SelectorName, Name: nm},
-	}, nil
+	return []*SelectorExpr{sel}, nil
 }
 
 func (p *Parser) parseDotSelector() (*SelectorExpr, error) {
 	if p.eof() {
-		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+		return nil, wrapPathError(
+			p.text, p.pos, ErrUnexpectedToken, tokenName, "*",
+		)
 	}
+	start := p.pos
 	if p.peek() == '*' {
 		p.pos++
-		return &SelectorExpr{Kind: SelectorWildcard}, nil
+		return &SelectorExpr{
+			Kind: SelectorWildcard,
+			Span: p.span(start),
+		}, nil
 	}
 	nm, ok := p.parseMemberName()
 	if !ok {
-		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+		return nil, wrapPathError(
+			p.text, p.pos, ErrUnexpectedToken, tokenName, "*",
+		)
 	}
 	return &SelectorExpr{
 		Kind: SelectorName,
 		Name: nm,
+		Span: p.span(start),
 	}, nil
 }
 
 func (p *Parser) parseBracketSelectors() ([]*SelectorExpr, error) {
 	if !p.consume('[') {
-		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken, "[")
 	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
 	p.skipWS()
 	var sels []*SelectorExpr
 	for {
 		sel, err := p.parseBracketSelector()
+		switch {
+		case err == nil:
+			sels = append(sels, sel)
+		case p.recovered(err):
+			p.resync()
+		default:
+			return nil, err
+		}
+		more, err := p.nextElement(']', ErrUnexpectedToken, "]", ",")
 		if err != nil {
 			return nil, err
 		}
-		sels = append(sels, sel)
-		p.skipWS()
-		if p.consume(']') {
-			if len(sels) == 0 {
-				return nil, wrapPathError(
-					p.text,
-					p.pos,
-					ErrUnexpectedToken,
-				)
-			}
+		if !more {
 			return sels, nil
 		}
-		if !p.consume(',') {
-			return nil, wrapPathError(
-				p.text,
-				p.pos,
-				ErrUnexpectedToken,
-			)
-		}
-		p.skipWS()
 	}
 }
 
 func (p *Parser) parseBracketSelector() (*SelectorExpr, error) {
+	start := p.pos
+	sel, err := p.parseBracketSelectorKind()
+	if err != nil {
+		return nil, err
+	}
+	sel.Span = p.span(start)
+	return sel, nil
+}
+
+func (p *Parser) parseBracketSelectorKind() (*SelectorExpr, error) {
 	if p.eof() {
-		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+		return nil, wrapPathError(
+			p.text, p.pos, ErrUnexpectedToken, selectorTokens...,
+		)
 	}
 	switch p.peek() {
 	case '*':
@@ -235,6 +323,12 @@ func (p *Parser) parseBracketSelector() (*SelectorExpr, error) {
 		}
 		return &SelectorExpr{Kind: SelectorName, Name: s}, nil
 	default:
+		if p.Options.legacy() {
+			sel, ok, err := p.parseLegacySelector()
+			if ok {
+				return sel, err
+			}
+		}
 		return p.parseIndexOrSlice()
 	}
 }
@@ -248,9 +342,7 @@ func (p *Parser) parseIndexOrSlice() (*SelectorExpr, error) {
 		n, ok := p.parseIntLiteral()
 		if !ok {
 			return nil, wrapPathError(
-				p.text,
-				p.pos,
-				ErrUnexpectedToken,
+				p.text, p.pos, ErrUnexpectedToken, selectorTokens...,
 			)
 		}
 		hasStart = true
@@ -259,7 +351,9 @@ func (p *Parser) parseIndexOrSlice() (*SelectorExpr, error) {
 	p.skipWS()
 	if p.peek() != ':' {
 		if !hasStart {
-			return nil, wrapPathError(p.text, startPos, ErrBadSlice)
+			return nil, wrapPathError(
+				p.text, startPos, ErrBadSlice, tokenInteger, ":",
+			)
 		}
 		return &SelectorExpr{Kind: SelectorIndex, Index: start}, nil
 	}
@@ -270,7 +364,7 @@ func (p *Parser) parseIndexOrSlice() (*SelectorExpr, error) {
 	if !p.eof() && p.peek() != ':' && p.peek() != ',' && p.peek() != ']' {
 		n, ok := p.parseIntLiteral()
 		if !ok {
-			return nil, wrapPathError(p.text, p.pos, ErrBadSlice)
+			return nil, wrapPathError(p.text, p.pos, ErrBadSlice, tokenInteger)
 		}
 		hasEnd = true
 		end = n
@@ -282,7 +376,9 @@ func (p *Parser) parseIndexOrSlice() (*SelectorExpr, error) {
 		if !p.eof() && p.peek() != ',' && p.peek() != ']' {
 			n, ok := p.parseIntLiteral()
 			if !ok {
-				return nil, wrapPathError(p.text, p.pos, ErrBadSlice)
+				return nil, wrapPathError(
+					p.text, p.pos, ErrBadSlice, tokenInteger,
+				)
 			}
 			step = n
 		}
@@ -321,7 +417,7 @@ func (p *Parser) parseOr() (FilterExpr, error) {
 		if err != nil {
 			return nil, err
 		}
-		left = &BinaryExpr{Op: "||", Left: left, Right: right}
+		left = binaryExpr("||", left, right)
 	}
 }
 
@@ -339,19 +435,33 @@ func (p *Parser) parseAnd() (FilterExpr, error) {
 		if err != nil {
 			return nil, err
 		}
-		left = &BinaryExpr{Op: "&&", Left: left, Right: right}
+		left = binaryExpr("&&", left, right)
 	}
 }
 
 func (p *Parser) parseCompare() (FilterExpr, error) {
-	left, err := p.parseUnary()
+	left, err := p.parseOperand()
 	if err != nil {
 		return nil, err
 	}
 	for {
 		p.skipWS()
-		op := ""
+		if p.Options.legacy() && !p.Options.extension(ExtensionMembership) {
+			ex, ok, err := p.parseLegacyMembership(left)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				left = ex
+				continue
+			}
+		}
+		op, _ := p.parseMembershipOp()
 		switch {
+		case op != "":
+		case p.Options.extension(ExtensionRegexMatch) &&
+			p.consumeString("=~"):
+			op = "=~"
 		case p.consumeString("=="):
 			op = "=="
 		case p.consumeString("!="):
@@ -368,22 +478,35 @@ func (p *Parser) parseCompare() (FilterExpr, error) {
 		if op == "" {
 			return left, nil
 		}
-		right, err := p.parseUnary()
+		right, err := p.parseOperand()
 		if err != nil {
 			return nil, err
 		}
-		left = &BinaryExpr{Op: op, Left: left, Right: right}
+		left = binaryExpr(op, p.lowerLength(left), p.lowerLength(right))
+	}
+}
+
+// parseOperand parses a comparison operand, which may be an arithmetic
+// expression when ExtensionArithmetic is enabled
+func (p *Parser) parseOperand() (FilterExpr, error) {
+	if p.Options.extension(ExtensionArithmetic) {
+		return p.parseAdditive()
 	}
+	return p.parseUnary()
 }
 
 func (p *Parser) parseUnary() (FilterExpr, error) {
 	p.skipWS()
+	start := p.pos
 	if p.consume('!') {
 		ex, err := p.parseUnary()
 		if err != nil {
 			return nil, err
 		}
-		return &UnaryExpr{Op: "!", Expr: ex}, nil
+		return &UnaryExpr{Op: "!", Expr: ex, Span: p.span(start)}, nil
+	}
+	if p.isNegation() {
+		return p.parseNegation()
 	}
 	return p.parsePrimary()
 }
@@ -391,72 +514,97 @@ func (p *Parser) parseUnary() (FilterExpr, error) {
 func (p *Parser) parsePrimary() (FilterExpr, error) {
 	p.skipWS()
 	if p.eof() {
-		return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+		return nil, wrapPathError(
+			p.text, p.pos, ErrUnexpectedToken, operandTokens...,
+		)
 	}
+	if p.isCompositeStart() {
+		return p.parseCompositeLiteral()
+	}
+	start := p.pos
 	switch p.peek() {
 	case '(':
 		p.pos++
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
 		ex, err := p.parseExpr()
 		if err != nil {
 			return nil, err
 		}
 		p.skipWS()
 		if !p.consume(')') {
-			return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+			err := wrapPathError(p.text, p.pos, ErrUnexpectedToken, ")")
+			if !p.recovered(err) {
+				return nil, err
+			}
 		}
+		setSpan(ex, p.span(start))
 		return ex, nil
 	case '\'', '"':
 		s, err := p.parseString()
 		if err != nil {
 			return nil, err
 		}
-		return &LiteralExpr{Value: s}, nil
-	case '$':
+		return &LiteralExpr{Value: s, Span: p.span(start)}, nil
+	case '$', '@':
 		p.pos++
-		path, err := p.parseRelativePath()
+		path, err := p.parseRelativePath(start)
 		if err != nil {
 			return nil, err
 		}
-		return &PathValueExpr{Absolute: true, Path: path}, nil
-	case '@':
-		p.pos++
-		path, err := p.parseRelativePath()
-		if err != nil {
-			return nil, err
-		}
-		return &PathValueExpr{Absolute: false, Path: path}, nil
+		return &PathValueExpr{
+			Absolute: p.src[start] == '$',
+			Path:     path,
+			Span:     path.Span,
+		}, nil
 	default:
 		if isNumberStart(p.peek()) {
 			n, ok := p.parseNumberLiteral()
 			if !ok {
-				return nil, wrapPathError(p.text, p.pos, ErrBadNumber)
+				return nil, wrapPathError(
+					p.text, p.pos, ErrBadNumber, tokenNumber,
+				)
 			}
-			return &LiteralExpr{Value: n}, nil
+			return &LiteralExpr{Value: n, Span: p.span(start)}, nil
 		}
 		if p.consumeString("true") {
-			return &LiteralExpr{Value: true}, nil
+			return &LiteralExpr{Value: true, Span: p.span(start)}, nil
 		}
 		if p.consumeString("false") {
-			return &LiteralExpr{Value: false}, nil
+			return &LiteralExpr{Value: false, Span: p.span(start)}, nil
 		}
 		if p.consumeString("null") {
-			return &LiteralExpr{Value: nil}, nil
+			return &LiteralExpr{Value: nil, Span: p.span(start)}, nil
 		}
 		if ident, ok := p.parseIdentifier(); ok {
 			if !p.consume('(') {
-				return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+				return nil, wrapPathError(
+					p.text, p.pos, ErrUnexpectedToken, "(",
+				)
 			}
 			args, err := p.parseCallArgs()
 			if err != nil {
 				return nil, err
 			}
-			return &FuncExpr{Name: ident, Args: args}, nil
+			return &FuncExpr{
+				Name: ident,
+				Args: args,
+				Span: p.span(start),
+			}, nil
 		}
 	}
-	return nil, wrapPathError(p.text, p.pos, ErrUnexpectedToken)
+	return nil, wrapPathError(
+		p.text, p.pos, ErrUnexpectedToken, operandTokens...,
+	)
 }
 
 func (p *Parser) parseCallArgs() ([]FilterExpr, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
 	p.skipWS()
 	if p.consume(')') {
 		return nil, nil
@@ -464,18 +612,106 @@ func (p *Parser) parseCallArgs() ([]FilterExpr, error) {
 	var res []FilterExpr
 	for {
 		ex, err := p.parseExpr()
+		switch {
+		case err == nil:
+			res = append(res, ex)
+		case p.recovered(err):
+			p.resync()
+		default:
+			return nil, err
+		}
+		more, err := p.nextElement(')', ErrBadFunc, ",", ")")
 		if err != nil {
 			return nil, err
 		}
-		res = append(res, ex)
-		p.skipWS()
-		if p.consume(')') {
+		if !more {
 			return res, nil
 		}
+	}
+}
+
+// nextElement moves past the ',' or closing rune that follows an element
+// of a bracketed list, reporting whether another element follows. When
+// recovering, anything else is recorded and skipped
+func (p *Parser) nextElement(
+	end rune, code error, expected ...string,
+) (bool, error) {
+	p.skipWS()
+	if p.consume(end) {
+		return false, nil
+	}
+	if !p.consume(',') {
+		err := wrapPathError(p.text, p.pos, code, expected...)
+		if !p.recovered(err) {
+			return false, err
+		}
+		p.resync()
 		if !p.consume(',') {
-			return nil, wrapPathError(p.text, p.pos, ErrBadFunc)
+			p.consume(end)
+			return false, nil
+		}
+	}
+	p.skipWS()
+	return true, nil
+}
+
+// recovered records a parse error when recovering, reporting whether
+// parsing may continue. Only the first error at each offset is kept
+func (p *Parser) recovered(err error) bool {
+	var qe *QueryError
+	if !p.recovering || !errors.As(err, &qe) {
+		return false
+	}
+	for _, e := range p.errs {
+		if e.RuneOffset == qe.RuneOffset {
+			return true
+		}
+	}
+	p.errs = append(p.errs, qe)
+	return true
+}
+
+// resync skips ahead to the next ',' or closing bracket that is not
+// nested within brackets, parentheses, or a string
+func (p *Parser) resync() {
+	depth := 0
+	for !p.eof() {
+		switch r := p.peek(); r {
+		case '\'', '"':
+			p.skipString(r)
+			continue
+		case '[', '(':
+			depth++
+		case ']', ')':
+			if depth == 0 {
+				return
+			}
+			depth--
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				return
+			}
+		}
+		p.pos++
+	}
+}
+
+func (p *Parser) skipString(q rune) {
+	for p.pos++; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '\\':
+			// a trailing backslash escapes nothing, so stop at the end
+			p.pos = min(p.pos+1, len(p.src)-1)
+		case q:
+			p.pos++
+			return
 		}
-		p.skipWS()
 	}
 }
 
@@ -681,8 +917,18 @@ func (p *Parser) consumeDigits() bool {
 	return true
 }
 
+// span returns the Span from start to the current position, less any
+// trailing whitespace that has already been skipped
+func (p *Parser) span(start int) Span {
+	end := min(p.pos, len(p.src))
+	for end > start && p.Options.isSpace(p.src[end-1]) {
+		end--
+	}
+	return Span{Start: start, End: end}
+}
+
 func (p *Parser) skipWS() {
-	for !p.eof() && unicode.IsSpace(p.peek()) {
+	for !p.eof() && p.Options.isSpace(p.peek()) {
 		p.pos++
 	}
 }
@@ -720,6 +966,39 @@ func (p *Parser) consumeString(v string) bool {
 	return true
 }
 
+func binaryExpr(op string, left, right FilterExpr) *BinaryExpr {
+	return &BinaryExpr{
+		Op:    op,
+		Left:  left,
+		Right: right,
+		Span: Span{
+			Start: left.span().Start,
+			End:   right.span().End,
+		},
+	}
+}
+
+// setSpan widens the span of a parenthesized expression to include its
+// parentheses
+func setSpan(ex FilterExpr, sp Span) {
+	switch v := ex.(type) {
+	case *LiteralExpr:
+		v.Span = sp
+	case *PathValueExpr:
+		v.Span = sp
+	case *UnaryExpr:
+		v.Span = sp
+	case *BinaryExpr:
+		v.Span = sp
+	case *ArithmeticExpr:
+		v.Span = sp
+	case *NegateExpr:
+		v.Span = sp
+	case *FuncExpr:
+		v.Span = sp
+	}
+}
+
 func isDigit(r rune) bool {
 	return r >= '0' && r <= '9'
 }
@@ -761,9 +1040,3 @@ func isNamePart(r rune) bool {
 	}
 	return unicode.IsDigit(r)
 }
-
-func wrapPathError(query string, pos int, err error) error {
-	return fmt.Errorf(
-		"%w at offset %d in %q: %w", ErrInvalidPath, pos, query, err,
-	)
-}
@@ -0,0 +1,169 @@
+package jpath_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+type recoveredError struct {
+	offset int
+	code   error
+}
+
+func recoverErrors(t *testing.T, err error) []recoveredError {
+	t.Helper()
+	var errs jpath.QueryErrors
+	if !assert.ErrorAs(t, err, &errs) {
+		t.FailNow()
+	}
+	res := make([]recoveredError, len(errs))
+	for i, qe := range errs {
+		res[i] = recoveredError{offset: qe.RuneOffset, code: qe.Code}
+	}
+	return res
+}
+
+func TestParseRecover(t *testing.T) {
+	cases := []struct {
+		query string
+		path  string
+		errs  []recoveredError
+	}{
+		{
+			query: `$[1 2, 'a', ?@.x == ]`,
+			path:  `$[1, 'a']`,
+			errs: []recoveredError{
+				{4, jpath.ErrUnexpectedToken},
+				{20, jpath.ErrUnexpectedToken},
+			},
+		},
+		{
+			query: `$[?length(@.a @.b) > 1, ?foo(]`,
+			path:  `$[?length(@.a) > 1, ?foo()]`,
+			errs: []recoveredError{
+				{14, jpath.ErrBadFunc},
+				{25, jpath.ErrUnknownFunc},
+				{29, jpath.ErrUnexpectedToken},
+			},
+		},
+		{
+			query: `$[?(@.a, 1].b`,
+			path:  `$[?@.a, 1].b`,
+			errs: []recoveredError{
+				{7, jpath.ErrUnexpectedToken},
+			},
+		},
+		{
+			query: `$.a[?@.* == 1, ?count(@) ]`,
+			path:  `$.a[?@[*] == 1, ?count(@)]`,
+			errs: []recoveredError{
+				{5, jpath.ErrCompRequiresSingularQuery},
+				{16, jpath.ErrFuncResultMustBeCompared},
+			},
+		},
+		{
+			query: `$["a]",1 x].b`,
+			path:  `$['a]', 1].b`,
+			errs: []recoveredError{
+				{9, jpath.ErrUnexpectedToken},
+			},
+		},
+		{
+			query: `$[?@.a && 1, 2`,
+			path:  `$[?@.a && 1, 2]`,
+			errs: []recoveredError{
+				{10, jpath.ErrLiteralMustBeCompared},
+				{14, jpath.ErrUnexpectedToken},
+			},
+		},
+		{
+			query: `$["\"c"\`,
+			path:  `$['"c']`,
+			errs: []recoveredError{
+				{7, jpath.ErrUnexpectedToken},
+			},
+		},
+		{
+			query: "$['a',\n-b'\\",
+			path:  `$.a`,
+			errs: []recoveredError{
+				{7, jpath.ErrUnexpectedToken},
+				{11, jpath.ErrUnexpectedToken},
+			},
+		},
+	}
+	for _, tc := range cases {
+		path, err := jpath.ParseRecover(tc.query)
+		assert.ErrorIs(t, err, jpath.ErrInvalidPath, tc.query)
+		if assert.NotNil(t, path, tc.query) {
+			assert.Equal(t, tc.path, path.String(), tc.query)
+		}
+		assert.Equal(t, tc.errs, recoverErrors(t, err), tc.query)
+	}
+}
+
+func FuzzParseRecover(f *testing.F) {
+	for _, query := range []string{
+		`$[1 2, 'a', ?@.x == ]`, `$["a]",1 x].b`, `$["\"c"\`, "$['a',\n-b'\\",
+	} {
+		f.Add(query)
+	}
+	// ParseRecover validates as well as parsing, so it should fail exactly
+	// when compiling the query does
+	f.Fuzz(func(t *testing.T, query string) {
+		_, err := jpath.CompileQuery(query)
+		_, rerr := jpath.ParseRecover(query)
+		if (err == nil) != (rerr == nil) {
+			t.Errorf("%q: CompileQuery error %v, ParseRecover error %v",
+				query, err, rerr,
+			)
+		}
+	})
+}
+
+func TestParseRecoverValid(t *testing.T) {
+	path, err := jpath.ParseRecover(`$.a[?@.b == 1, 2]`)
+	assert.Nil(t, err)
+	assert.Equal(t, jpath.MustParse(`$.a[?@.b == 1, 2]`), path)
+}
+
+func TestParseRecoverUnrecoverable(t *testing.T) {
+	path, err := jpath.ParseRecover(` $.a`)
+	assert.Nil(t, path)
+	assert.Equal(t,
+		[]recoveredError{{0, jpath.ErrExpectedRoot}}, recoverErrors(t, err),
+	)
+}
+
+func TestParseRecoverErrors(t *testing.T) {
+	query := `$[?@.a && 1, 2 3]`
+	_, err := jpath.ParseRecover(query)
+	assert.ErrorIs(t, err, jpath.ErrLiteralMustBeCompared)
+	assert.ErrorIs(t, err, jpath.ErrUnexpectedToken)
+	assert.Equal(t,
+		`invalid JSONPath query at offset 10 in "`+query+`": `+
+			"literal must be compared\n"+
+			`invalid JSONPath query at offset 15 in "`+query+`": `+
+			"unexpected token",
+		err.Error(),
+	)
+
+	// Parse still stops at the first error
+	_, err = jpath.Parse(query)
+	var errs jpath.QueryErrors
+	assert.False(t, errors.As(err, &errs))
+}
+
+func TestParserRecoverReuse(t *testing.T) {
+	var p jpath.Parser
+	_, err := p.ParseRecover(`$[1 2]`)
+	assert.Error(t, err)
+	_, err = p.ParseRecover(`$[1]`)
+	assert.NoError(t, err)
+	_, err = p.Parse(`$[1 2]`)
+	assert.ErrorIs(t, err, jpath.ErrUnexpectedToken)
+}
@@ -0,0 +1,390 @@
+package jpath
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	structField struct {
+		name      string
+		index     []int
+		tagged    bool
+		omitEmpty bool
+		omitZero  bool
+		quoted    bool
+	}
+)
+
+var (
+	structFieldCache sync.Map
+
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func (reflectModel) Kind(node any) NodeKind {
+	rv := reflectValue(node)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return NodeNull
+	case reflect.Bool:
+		return NodeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32,
+		reflect.Float64:
+		return NodeNumber
+	case reflect.String:
+		return NodeString
+	case reflect.Slice:
+		if rv.IsNil() {
+			return NodeNull
+		}
+		if isByteSlice(rv) {
+			return NodeString
+		}
+		return NodeArray
+	case reflect.Array:
+		return NodeArray
+	case reflect.Map:
+		if rv.IsNil() {
+			return NodeNull
+		}
+		return NodeObject
+	case reflect.Struct:
+		return NodeObject
+	default:
+		return NodeOther
+	}
+}
+
+func (reflectModel) Member(node any, name string) (any, bool) {
+	rv := reflectValue(node)
+	switch rv.Kind() {
+	case reflect.Struct:
+		for _, f := range structFields(rv.Type()) {
+			if f.name == name {
+				return f.value(rv)
+			}
+		}
+		return nil, false
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			key := reflect.ValueOf(name).Convert(rv.Type().Key())
+			value := rv.MapIndex(key)
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			if mapKeyString(iter.Key()) == name {
+				return iter.Value().Interface(), true
+			}
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+}
+
+func (reflectModel) Keys(node any) []string {
+	rv := reflectValue(node)
+	switch rv.Kind() {
+	case reflect.Struct:
+		var res []string
+		for _, f := range structFields(rv.Type()) {
+			if _, ok := f.value(rv); ok {
+				res = append(res, f.name)
+			}
+		}
+		// members are visited in key order, whether they come from a
+		// struct or a map, as they are for a decoded JSON object
+		sort.Strings(res)
+		return res
+	case reflect.Map:
+		res := make([]string, 0, rv.Len())
+		for _, key := range rv.MapKeys() {
+			res = append(res, mapKeyString(key))
+		}
+		sort.Strings(res)
+		return res
+	default:
+		return nil
+	}
+}
+
+func (reflectModel) Len(node any) int {
+	rv := reflectValue(node)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv.Len()
+	default:
+		return 0
+	}
+}
+
+func (reflectModel) Index(node any, idx int) any {
+	return reflectValue(node).Index(idx).Interface()
+}
+
+func (reflectModel) Scalar(node any) any {
+	rv := reflectValue(node)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if isByteSlice(rv) {
+			return base64.StdEncoding.EncodeToString(rv.Bytes())
+		}
+		return node
+	default:
+		return node
+	}
+}
+
+// reflectValue dereferences pointers and interfaces, and replaces values
+// that marshal themselves with their decoded JSON representation
+func reflectValue(node any) reflect.Value {
+	rv := reflect.ValueOf(node)
+	for rv.IsValid() {
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if rv.IsNil() {
+				return reflect.Value{}
+			}
+		}
+		if isSelfMarshaling(rv.Type()) {
+			return reflect.ValueOf(remarshal(rv))
+		}
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			rv = rv.Elem()
+		default:
+			return rv
+		}
+	}
+	return rv
+}
+
+func isSelfMarshaling(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+func remarshal(rv reflect.Value) any {
+	buf, err := json.Marshal(rv.Interface())
+	if err != nil {
+		return nil
+	}
+	var res any
+	if err := json.Unmarshal(buf, &res); err != nil {
+		return nil
+	}
+	return res
+}
+
+func isByteSlice(rv reflect.Value) bool {
+	elem := rv.Type().Elem()
+	if elem.Kind() != reflect.Uint8 {
+		return false
+	}
+	return !isSelfMarshaling(reflect.PointerTo(elem))
+}
+
+func mapKeyString(key reflect.Value) string {
+	switch key.Kind() {
+	case reflect.String:
+		return key.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10)
+	}
+	if tm, ok := key.Interface().(encoding.TextMarshaler); ok {
+		buf, err := tm.MarshalText()
+		if err == nil {
+			return string(buf)
+		}
+	}
+	return ""
+}
+
+func (f *structField) value(rv reflect.Value) (any, bool) {
+	fv, err := rv.FieldByIndexErr(f.index)
+	if err != nil {
+		return nil, false
+	}
+	if f.omitEmpty && isEmptyValue(fv) || f.omitZero && fv.IsZero() {
+		return nil, false
+	}
+	if f.quoted {
+		return quotedScalar(fv), true
+	}
+	return fv.Interface(), true
+}
+
+func quotedScalar(fv reflect.Value) string {
+	if fv.Kind() == reflect.String {
+		return strconv.Quote(fv.String())
+	}
+	buf, _ := json.Marshal(fv.Interface())
+	return string(buf)
+}
+
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface,
+		reflect.Pointer:
+		return rv.IsZero()
+	default:
+		return false
+	}
+}
+
+// structFields returns the JSON-visible fields of a struct type in the
+// order encoding/json would marshal them
+func structFields(t reflect.Type) []*structField {
+	if res, ok := structFieldCache.Load(t); ok {
+		return res.([]*structField)
+	}
+	var all []*structField
+	collectStructFields(t, nil, map[reflect.Type]bool{}, &all)
+	res := dominantFields(all)
+	structFieldCache.Store(t, res)
+	return res
+}
+
+func collectStructFields(
+	t reflect.Type, index []int, seen map[reflect.Type]bool,
+	out *[]*structField,
+) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if !sf.IsExported() && (!sf.Anonymous || ft.Kind() != reflect.Struct) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(index[:len(index):len(index)], i)
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			collectStructFields(ft, idx, seen, out)
+			continue
+		}
+		f := &structField{
+			name:   name,
+			index:  idx,
+			tagged: name != "",
+		}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for opt := range strings.SplitSeq(opts, ",") {
+			switch opt {
+			case "omitempty":
+				f.omitEmpty = true
+			case "omitzero":
+				f.omitZero = true
+			case "string":
+				f.quoted = isQuotableKind(ft.Kind())
+			}
+		}
+		*out = append(*out, f)
+	}
+}
+
+func isQuotableKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	default:
+		return false
+	}
+}
+
+func dominantFields(all []*structField) []*structField {
+	byName := map[string][]*structField{}
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	res := make([]*structField, 0, len(byName))
+	for _, fields := range byName {
+		if f, ok := dominantField(fields); ok {
+			res = append(res, f)
+		}
+	}
+	slices.SortFunc(res, func(l, r *structField) int {
+		return slices.Compare(l.index, r.index)
+	})
+	return res
+}
+
+func dominantField(fields []*structField) (*structField, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		depth = min(depth, len(f.index))
+	}
+	var shallow, tagged []*structField
+	for _, f := range fields {
+		if len(f.index) != depth {
+			continue
+		}
+		shallow = append(shallow, f)
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	switch {
+	case len(tagged) == 1:
+		return tagged[0], true
+	case len(tagged) == 0 && len(shallow) == 1:
+		return shallow[0], true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,126 @@
+package jpath_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+type (
+	reflectStore struct {
+		Name    string         `json:"name"`
+		Books   []*reflectBook `json:"books"`
+		Tags    map[string]int `json:"tags,omitempty"`
+		Opened  time.Time      `json:"opened"`
+		Secret  string         `json:"-"`
+		Counts  [2]uint8       `json:"counts"`
+		Raw     []byte         `json:"raw,omitempty"`
+		Extra   map[int]string `json:"extra,omitempty"`
+		private string
+		reflectAudit
+	}
+
+	reflectBook struct {
+		Title  string   `json:"title"`
+		Price  float32  `json:"price"`
+		Rating int64    `json:"rating,string"`
+		Note   *string  `json:"note,omitempty"`
+		Labels []string `json:"labels"`
+	}
+
+	reflectAudit struct {
+		Owner string `json:"owner"`
+	}
+)
+
+func reflectFixture() *reflectStore {
+	note := "signed"
+	return &reflectStore{
+		Name: "corner",
+		Books: []*reflectBook{
+			{Title: "a", Price: 8.5, Rating: 3, Labels: []string{"x"}},
+			{Title: "b", Price: 12, Rating: 5, Note: &note},
+			{Title: "c", Price: 30, Rating: 4, Labels: []string{}},
+		},
+		Tags:         map[string]int{"new": 2, "old": 1},
+		Opened:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Secret:       "hidden",
+		Counts:       [2]uint8{1, 2},
+		Extra:        map[int]string{7: "seven"},
+		private:      "p",
+		reflectAudit: reflectAudit{Owner: "kim"},
+	}
+}
+
+func TestReflectionQueries(t *testing.T) {
+	reg := jpath.NewRegistry().WithReflection()
+	store := reflectFixture()
+
+	assertQueries(t, reg, store, map[string][]any{
+		"$.books[?@.price < 20].title":          {"a", "b"},
+		"$.books[?@.note].title":                {"b"},
+		"$.books[?@.rating == '5'].price":       {float32(12)},
+		"$.books[?length(@.labels) == 0].title": {"c"},
+		"$.tags[*]":                             {2, 1},
+		"$.owner":                               {"kim"},
+		"$.extra['7']":                          {"seven"},
+		"$[?@ == '2024-01-02T03:04:05Z']":       {store.Opened},
+		"$.counts[-1]":                          {uint8(2)},
+		"$.secret":                              {},
+	})
+
+	got := reg.MustQuery("$.books[0]", store)
+	assert.Same(t, store.Books[0], got[0])
+}
+
+func TestReflectionMatchesMarshaled(t *testing.T) {
+	reflected := jpath.NewRegistry().WithReflection()
+	plain := jpath.NewRegistry()
+	store := reflectFixture()
+
+	buf, err := json.Marshal(store)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var doc any
+	if !assert.NoError(t, json.Unmarshal(buf, &doc)) {
+		return
+	}
+
+	for _, query := range []string{
+		"$..*",
+		"$.*",
+		"$..[?@.price > 10]",
+		"$..[?length(@) == 2]",
+		"$..[?count(@.*) > 1]",
+		"$.books[?@.labels == $.books[0].labels]",
+	} {
+		want := plain.MustLocate(query, doc).Paths()
+		got := reflected.MustLocate(query, store).Paths()
+		assert.Equal(t, want, got, query)
+	}
+}
+
+func TestReflectionAmbiguousFields(t *testing.T) {
+	type inner struct {
+		ID   int `json:"id"`
+		Name string
+	}
+	type other struct {
+		Name string
+	}
+	type outer struct {
+		inner
+		*other
+		Kind string `json:"kind"`
+	}
+	doc := outer{Kind: "k"}
+
+	reg := jpath.NewRegistry().WithReflection()
+	assert.Equal(t, []any{0, "k"}, reg.MustQuery("$.*", doc))
+	assert.Empty(t, reg.MustQuery("$.Name", doc))
+}
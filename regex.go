@@ -0,0 +1,118 @@
+package jpath
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrBadPattern indicates a literal regular expression pattern is invalid
+var ErrBadPattern = errors.New("invalid regular expression")
+
+// compilePattern compiles an I-Regexp pattern, anchoring it at both ends
+// when full is set. Compiled patterns are shared through regexCache, keyed
+// by how they're anchored as well as by their text
+func compilePattern(pattern string, full bool) (*regexp.Regexp, error) {
+	key := "s" + pattern
+	if full {
+		key = "m" + pattern
+	}
+	return regexCache.Get(key, func() (*regexp.Regexp, error) {
+		re2, err := translateIRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if full {
+			re2 = `\A(?:` + re2 + `)\z`
+		}
+		return regexp.Compile(re2)
+	})
+}
+
+// patternLiteral returns the pattern held by a string literal. Patterns
+// produced any other way are only known when a filter is evaluated
+func patternLiteral(ex FilterExpr) (string, bool) {
+	lit, ok := ex.(*LiteralExpr)
+	if !ok {
+		return "", false
+	}
+	s, ok := lit.Value.(string)
+	return s, ok
+}
+
+// validatePattern reports a literal pattern that can't be compiled, located
+// at the literal
+func validatePattern(ex FilterExpr, full bool) error {
+	_, _, err := literalPattern(ex, full)
+	return err
+}
+
+// validatePatternArg returns the Validator of a built-in pattern function,
+// which takes its pattern as the second argument
+func validatePatternArg(full bool) Validator {
+	return func(args []FilterExpr, _ FunctionUse, _ bool) error {
+		if len(args) != 2 {
+			return nil
+		}
+		return validatePattern(args[1], full)
+	}
+}
+
+// preparePatternCall returns the preparer of a built-in pattern function.
+// When the pattern is a literal, it is compiled once rather than looked up
+// for every node the filter visits
+func preparePatternCall(full bool) preparer {
+	return func(args []FilterExpr) (Evaluator, error) {
+		re, ok, err := literalPattern(args[1], full)
+		if !ok || err != nil {
+			return nil, err
+		}
+		return func(args []*Value) *Value {
+			return matchValue(args[0], re)
+		}, nil
+	}
+}
+
+// literalPattern compiles a literal pattern, reporting false when the
+// pattern isn't a literal
+func literalPattern(
+	ex FilterExpr, full bool,
+) (*regexp.Regexp, bool, error) {
+	pattern, ok := patternLiteral(ex)
+	if !ok {
+		return nil, false, nil
+	}
+	re, err := compilePattern(pattern, full)
+	if err != nil {
+		return nil, true, badPattern(ex, pattern, err)
+	}
+	return re, true, nil
+}
+
+func badPattern(ex FilterExpr, pattern string, err error) error {
+	return spanError(
+		ex.span(), fmt.Errorf("%w: %q: %w", ErrBadPattern, pattern, err),
+	)
+}
+
+// matchPattern builds the filter function of a =~ operator whose pattern
+// is a literal
+func matchPattern(left FilterFunc, re *regexp.Regexp) FilterFunc {
+	return func(ctx *FilterCtx) *Value {
+		return ScalarValue(toBool(matchValue(left(ctx), re)))
+	}
+}
+
+// matchValue matches a value against a compiled pattern. Anything other
+// than exactly one string is Nothing
+func matchValue(v *Value, re *regexp.Regexp) *Value {
+	raw, ok := v.singularValue()
+	if !ok {
+		return ScalarValue(nothing)
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return ScalarValue(nothing)
+	}
+	return ScalarValue(re.MatchString(s))
+}
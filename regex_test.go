@@ -0,0 +1,94 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func TestRegexMatchOperator(t *testing.T) {
+	doc := []any{
+		map[string]any{"name": "alpha", "pattern": "a.*"},
+		map[string]any{"name": "beta", "pattern": "b"},
+		map[string]any{"name": 1.0, "pattern": "1"},
+		map[string]any{"name": "gamma", "pattern": "("},
+	}
+	cases := map[string][]any{
+		`$[?@.name =~ 'a.*']`:                    {doc[0]},
+		`$[?@.name =~ 'et']`:                     {},
+		`$[?@.name =~ '.*et.*']`:                 {doc[1]},
+		`$[?@.name=~'[a-z]+mma']`:                {doc[3]},
+		`$[?@.name =~ @.pattern]`:                {doc[0]},
+		`$[?@.missing =~ '.*']`:                  {},
+		`$[?@.name =~ 'beta' || @.name == 1]`:    {doc[1], doc[2]},
+		`$[?!(@.name =~ 'a.*') && @.name > 'c']`: {doc[3]},
+	}
+	assertQueries(t, extensionRegistry(regex), doc, cases)
+}
+
+func TestBadPattern(t *testing.T) {
+	reg := extensionRegistry(regex)
+	cases := map[string]int{
+		`$[?match(@.a, 'a(')]`:        14,
+		`$[?search(@.a, '[')]`:        15,
+		`$[?@.a =~ '(' && @.b]`:       10,
+		`$[?@.b && (@.a =~ '[z-a]')]`: 18,
+	}
+	for query, offset := range cases {
+		_, err := reg.Query(query, nil)
+		assert.ErrorIs(t, err, jpath.ErrBadPattern, query)
+		var qe *jpath.QueryError
+		if assert.ErrorAs(t, err, &qe, query) {
+			assert.Equal(t, offset, qe.RuneOffset, query)
+		}
+	}
+
+	_, err := jpath.Compile(jpath.MustParse(`$[?match(@, '(')]`))
+	assert.ErrorIs(t, err, jpath.ErrBadPattern)
+
+	_, err = reg.ParseRecover(`$[?match(@.a, '(') && @.b =~ ')']`)
+	var errs jpath.QueryErrors
+	if assert.ErrorAs(t, err, &errs) && assert.Len(t, errs, 2) {
+		assert.ErrorIs(t, errs[0], jpath.ErrBadPattern)
+		assert.ErrorIs(t, errs[1], jpath.ErrBadPattern)
+	}
+}
+
+func TestLiteralPatterns(t *testing.T) {
+	// Literal patterns are compiled ahead of time, and must behave the same
+	// as patterns produced while a filter runs
+	doc := []any{
+		map[string]any{"s": "abc", "full": "a.c", "part": "b"},
+		map[string]any{"s": "a\nc", "full": "a.c", "part": "^a"},
+		map[string]any{"s": 1.0, "full": "1", "part": "1"},
+	}
+	cases := map[string]string{
+		`$[?match(@.s, 'a.c')]`:  `$[?match(@.s, $[0].full)]`,
+		`$[?search(@.s, 'b')]`:   `$[?search(@.s, $[0].part)]`,
+		`$[?search(@.s, '^a')]`:  `$[?search(@.s, $[1].part)]`,
+		`$[?match(@.s, '1')]`:    `$[?match(@.s, $[2].full)]`,
+		`$[?!match(@.s, 'a.c')]`: `$[?!match(@.s, $[0].full)]`,
+	}
+	for literal, dynamic := range cases {
+		want, err := jpath.Query(dynamic, doc)
+		if !assert.NoError(t, err, dynamic) {
+			continue
+		}
+		got, err := jpath.Query(literal, doc)
+		if assert.NoError(t, err, literal) {
+			assert.Equal(t, want, got, literal)
+		}
+	}
+}
+
+func TestMatchFilterFunc(t *testing.T) {
+	ctx := &jpath.FilterCtx{}
+	yes, no := jpath.ScalarValue(true), jpath.ScalarValue(false)
+	abc := jpath.Literal("abc")
+	assert.Equal(t, yes, jpath.Match(abc, jpath.Literal("a.c"))(ctx))
+	assert.Equal(t, no, jpath.Match(abc, jpath.Literal("b"))(ctx))
+	assert.Equal(t, no, jpath.Match(abc, jpath.Literal("("))(ctx))
+	assert.Equal(t, no, jpath.Match(jpath.Literal(1), abc)(ctx))
+}
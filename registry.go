@@ -1,21 +1,34 @@
 package jpath
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"maps"
+	"slices"
 )
 
 type (
 	// Registry stores function definitions and owns parse/compile/query methods
 	Registry struct {
 		functions map[string]*FunctionDefinition
+		adapter   Adapter
+		cache     *queryCache
+		parser    ParserOptions
 	}
 
-	// FunctionDefinition describes a filter function implementation
+	// FunctionDefinition describes a filter function implementation. Calls
+	// are checked against Signature when one is declared, and then by
+	// Validate when one is provided
 	FunctionDefinition struct {
-		Validate Validator
-		Eval     Evaluator
+		Signature *Signature
+		Validate  Validator
+		Eval      Evaluator
+
+		prepare preparer
 	}
 
 	// Validator validates function arguments for a call site
@@ -27,6 +40,10 @@ type (
 	// Function evaluates scalar arguments and returns a scalar result
 	Function func(args ...any) (any, bool)
 
+	// preparer returns an Evaluator specialized to a call's arguments, or
+	// nil to use Eval
+	preparer func(args []FilterExpr) (Evaluator, error)
+
 	// FunctionUse describes where a function appears in filter validation
 	FunctionUse uint8
 )
@@ -62,19 +79,47 @@ func NewRegistry() *Registry {
 
 // Clone makes an isolated copy of this registry
 func (r *Registry) Clone() *Registry {
-	res := *r
-	res.functions = maps.Clone(r.functions)
-	return &res
+	res := &Registry{
+		functions: maps.Clone(r.functions),
+		adapter:   r.adapter,
+		parser:    r.parser,
+	}
+	if r.cache != nil {
+		res.cache = newQueryCache(r.cache.size)
+	}
+	return res
+}
+
+// WithAdapter returns a copy of this registry whose compiled queries
+// navigate documents through the provided adapter, so trees other than
+// map[string]any and []any can be queried without converting them first.
+// Filters compare nodes as if they had been converted to their JSON
+// equivalents, but matches are returned as the original values
+func (r *Registry) WithAdapter(a Adapter) *Registry {
+	res := r.Clone()
+	if a == DefaultAdapter {
+		a = nil
+	}
+	res.adapter = a
+	return res
+}
+
+// WithReflection returns a copy of this registry that evaluates queries
+// against arbitrary Go values. Structs (using their json tag names and
+// options), pointers, typed maps, typed slices, and arrays are treated as
+// the JSON objects and arrays that encoding/json would marshal them to
+func (r *Registry) WithReflection() *Registry {
+	return r.WithAdapter(ReflectAdapter)
 }
 
 // RegisterDefinition registers a named function definition in this registry
 func (r *Registry) RegisterDefinition(
 	name string, def *FunctionDefinition,
 ) error {
-	if !isValidFunctionName(name) {
+	if !isIdentifier(name) {
 		return fmt.Errorf("%w: %s", ErrBadFuncName, name)
 	}
-	if def.Eval == nil {
+	if def.Eval == nil || def.Signature != nil && !def.Signature.valid() {
 		return fmt.Errorf("%w: %s", ErrBadFuncDefinition, name)
 	}
 	if r.functions == nil {
@@ -85,6 +130,9 @@ func (r *Registry) RegisterDefinition(
 		return fmt.Errorf("%w: %s", ErrFuncExists, name)
 	}
 	r.functions[name] = def
+	if r.cache != nil {
+		r.cache.reset()
+	}
 	return nil
 }
 
@@ -129,12 +177,36 @@ func (r *Registry) MustRegisterDefinition(
 	return r
 }
 
-// Parse parses a query string into a syntax tree
+// Parse parses a query string into a syntax tree, using the registry's
+// parser options
 func (r *Registry) Parse(query string) (*PathExpr, error) {
-	var p Parser
+	p := Parser{Options: r.parser}
 	return p.Parse(query)
 }
 
+// ParseRecover parses a query string, recovering from syntax errors as
+// Parser.ParseRecover does, and then validates every filter it could parse
+// against the registered functions. The QueryErrors it returns lists the
+// syntax and validation errors together, ordered by offset
+func (r *Registry) ParseRecover(query string) (*PathExpr, error) {
+	p := Parser{Options: r.parser}
+	path, err := p.ParseRecover(query)
+	var errs QueryErrors
+	errors.As(err, &errs)
+	if path != nil {
+		for _, e := range pathErrors(path, r) {
+			errs = append(errs, wrapQueryError(query, e))
+		}
+	}
+	if len(errs) == 0 {
+		return path, nil
+	}
+	slices.SortStableFunc(errs, func(a, b *QueryError) int {
+		return cmp.Compare(a.RuneOffset, b.RuneOffset)
+	})
+	return path, errs
+}
+
 // MustParse parses a query string or panics
 func (r *Registry) MustParse(query string) *PathExpr {
 	res, err := r.Parse(query)
@@ -159,16 +231,39 @@ func (r *Registry) MustCompile(path *PathExpr) Path {
 	return res
 }
 
+// CompileNodes compiles a parsed syntax tree into an executable NodePath
+func (r *Registry) CompileNodes(path *PathExpr) (*NodePath, error) {
+	c := &Compiler{registry: r}
+	return c.CompileNodes(path)
+}
+
+// MustCompileNodes compiles a parsed syntax tree into a NodePath or panics
+func (r *Registry) MustCompileNodes(path *PathExpr) *NodePath {
+	res, err := r.CompileNodes(path)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// CompileQuery parses and compiles a query string into an executable
+// NodePath, reporting a failure at either step as a *QueryError
+func (r *Registry) CompileQuery(query string) (*NodePath, error) {
+	return r.compileNodeQuery(query)
+}
+
+// CompileStream compiles a parsed syntax tree into an executable StreamPath
+func (r *Registry) CompileStream(path *PathExpr) (*StreamPath, error) {
+	c := &Compiler{registry: r}
+	return c.CompileStream(path)
+}
+
 // Query parses and compiles a query string, then runs it on a document
 func (r *Registry) Query(query string, document any) ([]any, error) {
-	ast, err := r.Parse(query)
+	run, err := r.compileQuery(query)
 	if err != nil {
 		return nil, err
 	}
-	run, err := r.Compile(ast)
-	if err != nil {
-		return nil, wrapPathError(query, 0, err)
-	}
 	return run(document), nil
 }
 
@@ -181,6 +276,142 @@ func (r *Registry) MustQuery(query string, document any) []any {
 	return res
 }
 
+// QueryContext parses and compiles a JSONPath query, then runs it on a
+// document within the budgets set by opts, stopping when ctx is done
+func (r *Registry) QueryContext(
+	ctx context.Context, query string, document any, opts EvalOptions,
+) ([]any, error) {
+	run, err := r.compileNodeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := run.EvalContext(ctx, document, opts)
+	if err != nil {
+		return nil, err
+	}
+	return res.Values(), nil
+}
+
+// Locate parses and compiles a query string, then runs it on a document,
+// returning every match along with its normalized path
+func (r *Registry) Locate(query string, document any) (NodeList, error) {
+	run, err := r.compileNodeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return run.Locate(document), nil
+}
+
+// MustLocate parses, compiles, and locates a query string or panics
+func (r *Registry) MustLocate(query string, document any) NodeList {
+	res, err := r.Locate(query, document)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// Exists parses and compiles a JSONPath query, then reports whether it
+// matches anything in a document, stopping at the first match
+func (r *Registry) Exists(query string, document any) (bool, error) {
+	run, err := r.compileNodeQuery(query)
+	if err != nil {
+		return false, err
+	}
+	return run.Exists(document), nil
+}
+
+// Stream parses and compiles a query string, then runs it against the JSON
+// document read from in, reporting each match to yield as it is found
+func (r *Registry) Stream(
+	query string, in io.Reader, yield func(*Node) bool,
+) error {
+	ast, err := r.Parse(query)
+	if err != nil {
+		return err
+	}
+	run, err := r.CompileStream(ast)
+	if err != nil {
+		return wrapQueryError(query, err)
+	}
+	return run.Stream(json.NewDecoder(in), yield)
+}
+
+// Set assigns value at every match of the query, creating missing members
+// named by a final name-only segment. It returns the resulting document
+// and the number of nodes written
+func (r *Registry) Set(
+	query string, document, value any,
+) (any, int, error) {
+	run, err := r.compileNodeQuery(query)
+	if err != nil {
+		return document, 0, err
+	}
+	res, count := run.Set(document, value)
+	return res, count, nil
+}
+
+// Replace assigns value at every existing match of the query. It returns
+// the resulting document and the number of nodes written
+func (r *Registry) Replace(
+	query string, document, value any,
+) (any, int, error) {
+	run, err := r.compileNodeQuery(query)
+	if err != nil {
+		return document, 0, err
+	}
+	res, count := run.Replace(document, value)
+	return res, count, nil
+}
+
+// Update replaces every match of the query with the result of fn. It
+// returns the resulting document and the number of nodes written
+func (r *Registry) Update(
+	query string, document any, fn UpdateFunc,
+) (any, int, error) {
+	run, err := r.compileNodeQuery(query)
+	if err != nil {
+		return document, 0, err
+	}
+	res, count := run.Update(document, fn)
+	return res, count, nil
+}
+
+// Delete removes every match of the query. It returns the resulting
+// document and the number of nodes removed
+func (r *Registry) Delete(query string, document any) (any, int, error) {
+	run, err := r.compileNodeQuery(query)
+	if err != nil {
+		return document, 0, err
+	}
+	res, count := run.Delete(document)
+	return res, count, nil
+}
+
+func (r *Registry) compileQueryText(query string) (Path, error) {
+	ast, err := r.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	run, err := r.Compile(ast)
+	if err != nil {
+		return nil, wrapQueryError(query, err)
+	}
+	return run, nil
+}
+
+func (r *Registry) compileNodeQueryText(query string) (*NodePath, error) {
+	ast, err := r.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	run, err := r.CompileNodes(ast)
+	if err != nil {
+		return nil, wrapQueryError(query, err)
+	}
+	return run, nil
+}
+
 func (r *Registry) function(name string) (*FunctionDefinition, bool) {
 	def, ok := r.functions[name]
 	return def, ok
@@ -205,7 +436,7 @@ func WrapFunction(fn Function) Evaluator {
 	}
 }
 
-func isValidFunctionName(name string) bool {
+func isIdentifier(name string) bool {
 	if name == "" {
 		return false
 	}
@@ -24,6 +24,20 @@ func TestRegistryParseCompileQuery(t *testing.T) {
 	assert.Equal(t, []any{float64(20)}, got)
 }
 
+func TestRegistryCompileQuery(t *testing.T) {
+	reg := jpath.NewRegistry()
+	path, err := reg.CompileQuery("$[1]")
+	if assert.NoError(t, err) {
+		got := path.Locate([]any{float64(10), float64(20)})
+		assert.Equal(t, []any{float64(20)}, got.Values())
+	}
+
+	_, err = reg.CompileQuery("$[?length(@.*) > 1]")
+	qe := queryError(t, err)
+	assert.Equal(t, jpath.ErrFuncRequiresSingularQuery, qe.Code)
+	assert.Equal(t, 10, qe.RuneOffset)
+}
+
 func TestRegistryMustHelpers(t *testing.T) {
 	reg := jpath.NewRegistry()
 	_ = reg.MustParse("$[0]")
@@ -310,8 +324,17 @@ func assertRegistryQuery(
 ) {
 	t.Helper()
 	got, err := reg.Query(query, doc)
-	if !assert.NoError(t, err) {
+	if !assert.NoError(t, err, query) {
 		return
 	}
-	assert.Equal(t, want, got)
+	assert.Equal(t, want, got, query)
+}
+
+func assertQueries(
+	t *testing.T, reg *jpath.Registry, doc any, cases map[string][]any,
+) {
+	t.Helper()
+	for query, want := range cases {
+		assertRegistryQuery(t, reg, query, doc, want)
+	}
 }
@@ -0,0 +1,157 @@
+package jpath
+
+import (
+	"errors"
+	"fmt"
+)
+
+type (
+	// FunctionType is the RFC 9535 type of a function parameter or result
+	FunctionType uint8
+
+	// Signature declares the parameter and result types of a function. A
+	// definition with a Signature has every call site checked against the
+	// well-typedness rules of RFC 9535, and has its arguments converted to
+	// the declared types before they reach its Evaluator
+	Signature struct {
+		Params []FunctionType
+		Result FunctionType
+	}
+)
+
+const (
+	ValueType   FunctionType = iota // a JSON value, or Nothing
+	LogicalType                     // true or false
+	NodesType                       // a list of nodes
+)
+
+// ErrFuncArgumentType is raised when an argument can't have a parameter's type
+var ErrFuncArgumentType = errors.New("function argument has wrong type")
+
+// String returns the RFC 9535 name of the type
+func (t FunctionType) String() string {
+	switch t {
+	case ValueType:
+		return "ValueType"
+	case LogicalType:
+		return "LogicalType"
+	case NodesType:
+		return "NodesType"
+	default:
+		return "unknown type"
+	}
+}
+
+func (t FunctionType) valid() bool {
+	return t <= NodesType
+}
+
+func (s *Signature) valid() bool {
+	for _, p := range s.Params {
+		if !p.valid() {
+			return false
+		}
+	}
+	return s.Result.valid()
+}
+
+// check validates a call to the named function against the signature.
+// Calls made as arguments are checked by the function receiving them, which
+// knows the type it expects
+func (s *Signature) check(
+	f *FuncExpr, ctx exprContext, registry *Registry,
+) error {
+	if err := validateFunctionArity(f.Name, f.Args, len(s.Params)); err != nil {
+		return err
+	}
+	switch {
+	case ctx == contextLogical && s.Result == ValueType:
+		return fmt.Errorf("%w: %s", ErrFuncResultMustBeCompared, f.Name)
+	case ctx == contextComparisonOperand && s.Result != ValueType:
+		return fmt.Errorf("%w: %s", ErrFuncResultMustNotBeCompared, f.Name)
+	}
+	for idx, arg := range f.Args {
+		err := checkArgType(f.Name, idx, arg, s.Params[idx], registry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkArgType(
+	name string, idx int, arg FilterExpr, param FunctionType,
+	registry *Registry,
+) error {
+	switch a := arg.(type) {
+	case *LiteralExpr:
+		if param == ValueType {
+			return nil
+		}
+	case *PathValueExpr:
+		if param != ValueType || isSingularPath(a.Path) {
+			return nil
+		}
+		return spanError(
+			a.Span, fmt.Errorf("%w: %s", ErrFuncRequiresSingularQuery, name),
+		)
+	case *FuncExpr:
+		def, ok := registry.function(a.Name)
+		if !ok || def.Signature == nil {
+			// unknown functions are reported when the argument is validated,
+			// and untyped ones are trusted to produce what is needed
+			return nil
+		}
+		if convertsTo(def.Signature.Result, param) {
+			return nil
+		}
+	case *UnaryExpr, *BinaryExpr:
+		if param == LogicalType {
+			return nil
+		}
+	case *ArithmeticExpr, *NegateExpr:
+		if param == ValueType {
+			return nil
+		}
+	}
+	if param == NodesType {
+		return spanError(arg.span(), fmt.Errorf(
+			"%w: %s requires query argument",
+			ErrFuncRequiresQueryArgument, name,
+		))
+	}
+	return spanError(arg.span(), fmt.Errorf(
+		"%w: %s argument %d must be %s",
+		ErrFuncArgumentType, name, idx+1, param,
+	))
+}
+
+// convertsTo reports whether a function result of type from can be passed
+// as a parameter of type to. A node list converts to a logical value by
+// being non-empty
+func convertsTo(from, to FunctionType) bool {
+	return from == to || from == NodesType && to == LogicalType
+}
+
+// convertArg converts a compiled argument to its declared parameter type
+func convertArg(param FunctionType, arg FilterFunc) FilterFunc {
+	switch param {
+	case ValueType:
+		return func(ctx *FilterCtx) *Value {
+			v := arg(ctx)
+			if !v.IsNodes {
+				return v
+			}
+			if len(v.Nodes) != 1 {
+				return ScalarValue(nothing)
+			}
+			return ScalarValue(v.Nodes[0])
+		}
+	case LogicalType:
+		return func(ctx *FilterCtx) *Value {
+			return ScalarValue(toBool(arg(ctx)))
+		}
+	default:
+		return arg
+	}
+}
@@ -0,0 +1,159 @@
+package jpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func typedRegistry(t *testing.T) *jpath.Registry {
+	t.Helper()
+	reg := jpath.NewRegistry()
+	reg.MustRegisterDefinition("upper", &jpath.FunctionDefinition{
+		Signature: &jpath.Signature{
+			Params: []jpath.FunctionType{jpath.ValueType},
+			Result: jpath.ValueType,
+		},
+		Eval: func(args []*jpath.Value) *jpath.Value {
+			// ValueType arguments always arrive as scalars
+			assert.False(t, args[0].IsNodes)
+			s, ok := args[0].Scalar.(string)
+			if !ok {
+				return jpath.ScalarValue(jpath.Nothing)
+			}
+			return jpath.ScalarValue(s + "!")
+		},
+	})
+	reg.MustRegisterDefinition("either", &jpath.FunctionDefinition{
+		Signature: &jpath.Signature{
+			Params: []jpath.FunctionType{
+				jpath.LogicalType, jpath.LogicalType,
+			},
+			Result: jpath.LogicalType,
+		},
+		Eval: func(args []*jpath.Value) *jpath.Value {
+			return jpath.ScalarValue(
+				args[0].Scalar.(bool) || args[1].Scalar.(bool),
+			)
+		},
+	})
+	reg.MustRegisterDefinition("children", &jpath.FunctionDefinition{
+		Signature: &jpath.Signature{
+			Params: []jpath.FunctionType{jpath.NodesType},
+			Result: jpath.NodesType,
+		},
+		Eval: func(args []*jpath.Value) *jpath.Value {
+			return args[0]
+		},
+	})
+	return reg
+}
+
+func TestSignatureEvaluation(t *testing.T) {
+	reg := typedRegistry(t)
+	doc := []any{
+		map[string]any{"a": "x", "b": []any{1.0}},
+		map[string]any{"a": "y"},
+		map[string]any{"c": true},
+	}
+	cases := map[string][]any{
+		`$[?upper(@.a) == 'x!']`:              {doc[0]},
+		`$[?upper(@.missing) == 'x!']`:        {},
+		`$[?either(@.b, @.c)]`:                {doc[0], doc[2]},
+		`$[?either(@.a == 'y', 1 == 2)]`:      {doc[1]},
+		`$[?children(@.b[*])]`:                {doc[0]},
+		`$[?either(children(@.c), @.x)]`:      {doc[2]},
+		`$[?length(upper(@.a)) == 2]`:         {doc[0], doc[1]},
+		`$[?upper(value(@.a)) == 'y!']`:       {doc[1]},
+		`$[?count(children(@.*)) == 2]`:       {doc[0]},
+		`$[?match(upper(@.a), '[xy]!')]`:      {doc[0], doc[1]},
+		`$[?either(match(@.a, 'x'), @.b)]`:    {doc[0]},
+		`$[?!either(@.a, @.c) && !@.b]`:       {},
+		`$[?upper(@.a) == upper('x') && @.b]`: {doc[0]},
+	}
+	assertQueries(t, reg, doc, cases)
+	for query := range cases {
+		_, err := reg.Compile(jpath.Optimize(reg.MustParse(query)))
+		assert.NoError(t, err, query)
+	}
+}
+
+func TestSignatureValidation(t *testing.T) {
+	reg := typedRegistry(t)
+	cases := map[string]error{
+		`$[?upper(@.a)]`:                jpath.ErrFuncResultMustBeCompared,
+		`$[?either(@.a, @.b) == true]`:  jpath.ErrFuncResultMustNotBeCompared,
+		`$[?children(@.*) == 1]`:        jpath.ErrFuncResultMustNotBeCompared,
+		`$[?upper(@.a, @.b) == 'x']`:    jpath.ErrInvalidFuncArity,
+		`$[?upper(@.*) == 'x']`:         jpath.ErrFuncRequiresSingularQuery,
+		`$[?upper(@.a == 1) == 'x']`:    jpath.ErrFuncArgumentType,
+		`$[?upper(children(@)) == 'x']`: jpath.ErrFuncArgumentType,
+		`$[?either(1, @.a)]`:            jpath.ErrFuncArgumentType,
+		`$[?either(upper(@.a), @.a)]`:   jpath.ErrFuncArgumentType,
+		`$[?children('x')]`:             jpath.ErrFuncRequiresQueryArgument,
+		`$[?children(upper(@))]`:        jpath.ErrFuncRequiresQueryArgument,
+		`$[?count(either(@, @)) == 1]`:  jpath.ErrFuncRequiresQueryArgument,
+		`$[?length(match(@, 'a')) > 1]`: jpath.ErrFuncArgumentType,
+		`$[?upper(nope(@)) == 'x']`:     jpath.ErrUnknownFunc,
+	}
+	for query, want := range cases {
+		_, err := reg.Query(query, nil)
+		assert.ErrorIs(t, err, want, query)
+		assert.ErrorIs(t, err, jpath.ErrInvalidPath, query)
+	}
+}
+
+func TestSignatureWithValidator(t *testing.T) {
+	reg := jpath.NewRegistry()
+	reg.MustRegisterDefinition("pick", &jpath.FunctionDefinition{
+		Signature: &jpath.Signature{
+			Params: []jpath.FunctionType{jpath.ValueType},
+			Result: jpath.ValueType,
+		},
+		Validate: func(
+			args []jpath.FilterExpr, _ jpath.FunctionUse, _ bool,
+		) error {
+			if _, ok := args[0].(*jpath.LiteralExpr); ok {
+				return nil
+			}
+			return jpath.ErrFuncArgumentType
+		},
+		Eval: func(args []*jpath.Value) *jpath.Value {
+			return args[0]
+		},
+	})
+
+	res, err := reg.Query(`$[?pick(1) == @]`, []any{1.0, 2.0})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0}, res)
+
+	_, err = reg.Query(`$[?pick(@) == 1]`, nil)
+	assert.ErrorIs(t, err, jpath.ErrFuncArgumentType)
+
+	_, err = reg.Query(`$[?pick(1)]`, nil)
+	assert.ErrorIs(t, err, jpath.ErrFuncResultMustBeCompared)
+}
+
+func TestSignatureRegistration(t *testing.T) {
+	reg := jpath.NewRegistry()
+	err := reg.RegisterDefinition("bad", &jpath.FunctionDefinition{
+		Signature: &jpath.Signature{
+			Params: []jpath.FunctionType{jpath.FunctionType(9)},
+		},
+		Eval: func(args []*jpath.Value) *jpath.Value { return args[0] },
+	})
+	assert.ErrorIs(t, err, jpath.ErrBadFuncDefinition)
+
+	assert.Equal(t, "ValueType", jpath.ValueType.String())
+	assert.Equal(t, "LogicalType", jpath.LogicalType.String())
+	assert.Equal(t, "NodesType", jpath.NodesType.String())
+	assert.Equal(t, "unknown type", jpath.FunctionType(9).String())
+}
+
+func TestNothing(t *testing.T) {
+	assert.True(t, jpath.ScalarValue(jpath.Nothing).IsNothing())
+	assert.True(t, jpath.NodesValue([]any{jpath.Nothing}).IsNothing())
+	assert.False(t, jpath.ScalarValue(nil).IsNothing())
+}
@@ -0,0 +1,149 @@
+package jpath_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func spanText(query string, sp jpath.Span) string {
+	return string([]rune(query)[sp.Start:sp.End])
+}
+
+// clearSpans zeroes every Span in a syntax tree, so trees parsed from
+// different text can be compared by structure alone
+func clearSpans(path *jpath.PathExpr) *jpath.PathExpr {
+	path.Span = jpath.Span{}
+	for _, sg := range path.Segments {
+		sg.Span = jpath.Span{}
+		for _, sel := range sg.Selectors {
+			sel.Span = jpath.Span{}
+			if sel.Filter != nil {
+				clearFilterSpans(sel.Filter)
+			}
+		}
+	}
+	return path
+}
+
+func clearFilterSpans(expr jpath.FilterExpr) {
+	switch v := expr.(type) {
+	case *jpath.LiteralExpr:
+		v.Span = jpath.Span{}
+	case *jpath.PathValueExpr:
+		v.Span = jpath.Span{}
+		clearSpans(v.Path)
+	case *jpath.UnaryExpr:
+		v.Span = jpath.Span{}
+		clearFilterSpans(v.Expr)
+	case *jpath.BinaryExpr:
+		v.Span = jpath.Span{}
+		clearFilterSpans(v.Left)
+		clearFilterSpans(v.Right)
+	case *jpath.ArithmeticExpr:
+		v.Span = jpath.Span{}
+		clearFilterSpans(v.Left)
+		clearFilterSpans(v.Right)
+	case *jpath.NegateExpr:
+		v.Span = jpath.Span{}
+		clearFilterSpans(v.Expr)
+	case *jpath.FuncExpr:
+		v.Span = jpath.Span{}
+		for _, arg := range v.Args {
+			clearFilterSpans(arg)
+		}
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	query := `$.é..x[ 1:3 , ?(@.b || $.c) && length(@.d) > 2 ]`
+	ast := jpath.MustParse(query)
+	text := func(sp jpath.Span) string {
+		return spanText(query, sp)
+	}
+
+	assert.Equal(t, query, text(ast.Span))
+	assert.Equal(t, ".é", text(ast.Segments[0].Span))
+	assert.Equal(t, "é", text(ast.Segments[0].Selectors[0].Span))
+	assert.Equal(t, "..x", text(ast.Segments[1].Span))
+	assert.Equal(t, "x", text(ast.Segments[1].Selectors[0].Span))
+
+	sg := ast.Segments[2]
+	assert.Equal(t, query[len(`$.é..x`):], text(sg.Span))
+	assert.Equal(t, "1:3", text(sg.Selectors[0].Span))
+
+	flt := sg.Selectors[1]
+	assert.Equal(t, "?(@.b || $.c) && length(@.d) > 2", text(flt.Span))
+
+	and := flt.Filter.(*jpath.BinaryExpr)
+	assert.Equal(t, "(@.b || $.c) && length(@.d) > 2", text(and.Span))
+
+	or := and.Left.(*jpath.BinaryExpr)
+	assert.Equal(t, "(@.b || $.c)", text(or.Span))
+	right := or.Right.(*jpath.PathValueExpr)
+	assert.Equal(t, "$.c", text(right.Span))
+	assert.Equal(t, "$.c", text(right.Path.Span))
+
+	cmp := and.Right.(*jpath.BinaryExpr)
+	assert.Equal(t, "length(@.d) > 2", text(cmp.Span))
+	fn := cmp.Left.(*jpath.FuncExpr)
+	assert.Equal(t, "length(@.d)", text(fn.Span))
+	assert.Equal(t, "@.d", text(fn.Args[0].(*jpath.PathValueExpr).Span))
+	assert.Equal(t, "2", text(cmp.Right.(*jpath.LiteralExpr).Span))
+}
+
+func TestParseSpansShorthand(t *testing.T) {
+	query := `!@.a`
+	ast := jpath.MustParse(query)
+	assert.Equal(t, query, spanText(query, ast.Span))
+	sel := ast.Segments[0].Selectors[0]
+	assert.Equal(t, query, spanText(query, sel.Span))
+	not := sel.Filter.(*jpath.UnaryExpr)
+	assert.Equal(t, query, spanText(query, not.Span))
+	path := not.Expr.(*jpath.PathValueExpr)
+	assert.Equal(t, "@.a", spanText(query, path.Span))
+}
+
+func TestErrorSpans(t *testing.T) {
+	cases := map[string]string{
+		`$[?@.a && 1]`:                   `1`,
+		`$.x[?@.* == 1]`:                 `@.*`,
+		`$[?@.a && nope(@)]`:             `nope(@)`,
+		`$[?@.a || count(@.b)]`:          `count(@.b)`,
+		`$[?length(@.a, @.b) > 1]`:       `length(@.a, @.b)`,
+		`$[?length(@..a) > 1]`:           `@..a`,
+		`$[?value('x') == 1]`:            `'x'`,
+		`$[?@.a && match(@.b, 'x') > 1]`: `match(@.b, 'x')`,
+	}
+	for query, want := range cases {
+		ast, err := jpath.Parse(query)
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+		_, err = jpath.Compile(ast)
+		var se *jpath.SpanError
+		if !assert.True(t, errors.As(err, &se), query) {
+			continue
+		}
+		assert.Equal(t, want, spanText(query, se.Span), query)
+
+		_, err = jpath.Query(query, nil)
+		assert.ErrorIs(t, err, jpath.ErrInvalidPath, query)
+		assert.ErrorAs(t, err, &se, query)
+		offset := fmt.Sprintf("at offset %d ", se.Span.Start)
+		assert.Contains(t, err.Error(), offset, query)
+	}
+}
+
+func TestOptimizeKeepsSpans(t *testing.T) {
+	query := `$[?!!@.a && 1 == 1]`
+	ast := jpath.Optimize(jpath.MustParse(query))
+	sel := ast.Segments[0].Selectors[0]
+	assert.Equal(t, "?!!@.a && 1 == 1", spanText(query, sel.Span))
+	path := sel.Filter.(*jpath.PathValueExpr)
+	assert.Equal(t, "@.a", spanText(query, path.Span))
+}
@@ -0,0 +1,310 @@
+package jpath
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type (
+	// StreamPath is a compiled query that runs against a JSON token stream.
+	// Matches are reported in document order as soon as they are complete,
+	// and only subtrees that are matched, or that a selector needs to see
+	// in full, are decoded into memory
+	StreamPath struct {
+		segments []*streamSegment
+		rest     []nodeSegment
+		fallback *NodePath
+	}
+
+	streamSegment struct {
+		keys       []streamKeyFunc
+		filters    []FilterFunc
+		descendant bool
+		buffer     bool
+	}
+
+	streamKeyFunc func(key any) bool
+
+	streamRun struct {
+		path  *StreamPath
+		dec   *json.Decoder
+		env   *evalEnv
+		yield nodeYield
+	}
+)
+
+var errStreamStopped = errors.New("stream stopped")
+
+// Stream reads the next JSON value from dec and reports each match to
+// yield, stopping early if yield returns false. Object members are visited
+// in document order, so when a segment has several selectors, or an object
+// has several matching members, matches may be ordered differently than by
+// NodePath. Queries with filters that refer to the root node ($) need the
+// whole document, so it is decoded before evaluation. Returns io.EOF when
+// the decoder has no more values
+func (p *StreamPath) Stream(dec *json.Decoder, yield func(*Node) bool) error {
+	if p.fallback != nil {
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+		p.fallback.each(doc, yield)
+		return nil
+	}
+	run := &streamRun{
+		path:  p,
+		dec:   dec,
+		env:   newEvalEnv(nil),
+		yield: yield,
+	}
+	err := run.visit(&Node{}, []int{0})
+	if errors.Is(err, errStreamStopped) {
+		return nil
+	}
+	return err
+}
+
+func compileStreamPath(
+	path *PathExpr, registry *Registry,
+) (*StreamPath, error) {
+	if registry.hasCustomAdapter() {
+		// decoded stream values are always plain encoding/json trees
+		registry = registry.WithAdapter(DefaultAdapter)
+	}
+	np, err := compileNodePath(path, registry)
+	if err != nil {
+		return nil, err
+	}
+	path = Optimize(path)
+	if referencesRoot(path) {
+		return &StreamPath{fallback: np}, nil
+	}
+	res := &StreamPath{
+		segments: make([]*streamSegment, len(path.Segments)),
+		rest:     make([]nodeSegment, len(path.Segments)+1),
+	}
+	nodeSegs := make([]nodeSegment, len(path.Segments))
+	for idx, sg := range path.Segments {
+		compiled, err := compileNodeSegment(sg, registry)
+		if err != nil {
+			return nil, err
+		}
+		nodeSegs[idx] = compiled
+		res.segments[idx], err = compileStreamSegment(sg, registry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for idx := range res.rest {
+		res.rest[idx] = composeNodeSegments(nodeSegs[idx:])
+	}
+	return res, nil
+}
+
+func compileStreamSegment(
+	segment *SegmentExpr, registry *Registry,
+) (*streamSegment, error) {
+	res := &streamSegment{descendant: segment.Descendant}
+	for _, sel := range segment.Selectors {
+		switch sel.Kind {
+		case SelectorName:
+			res.keys = append(res.keys, streamName(sel.Name))
+		case SelectorWildcard:
+			res.keys = append(res.keys, streamWildcard)
+		case SelectorIndex:
+			if sel.Index < 0 {
+				res.buffer = true
+				continue
+			}
+			res.keys = append(res.keys, streamIndex(sel.Index))
+		case SelectorSlice:
+			if !isStreamableSlice(sel.Slice) {
+				res.buffer = true
+				continue
+			}
+			res.keys = append(res.keys, streamSlice(sel.Slice))
+		case SelectorFilter:
+			filter, err := compileFilter(sel.Filter, registry)
+			if err != nil {
+				return nil, err
+			}
+			res.filters = append(res.filters, filter)
+		}
+	}
+	return res, nil
+}
+
+func (r *streamRun) visit(node *Node, states []int) error {
+	if len(states) == 0 {
+		return r.skip()
+	}
+	if r.mustBuffer(states) {
+		if err := r.dec.Decode(&node.Value); err != nil {
+			return err
+		}
+		return r.evaluate(node, states)
+	}
+	tok, err := r.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for r.dec.More() {
+			tok, err := r.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			if err := r.visitChild(node.child(key, nil), states); err != nil {
+				return err
+			}
+		}
+		_, err = r.dec.Token()
+		return err
+	case json.Delim('['):
+		for idx := 0; r.dec.More(); idx++ {
+			if err := r.visitChild(node.child(idx, nil), states); err != nil {
+				return err
+			}
+		}
+		_, err = r.dec.Token()
+		return err
+	default:
+		return nil
+	}
+}
+
+func (r *streamRun) visitChild(child *Node, states []int) error {
+	var next []int
+	filtered := false
+	for _, st := range states {
+		sg := r.path.segments[st]
+		for _, match := range sg.keys {
+			if match(child.key) {
+				next = append(next, st+1)
+			}
+		}
+		if sg.descendant {
+			next = append(next, st)
+		}
+		filtered = filtered || len(sg.filters) > 0
+	}
+	if !filtered {
+		return r.visit(child, next)
+	}
+	if err := r.dec.Decode(&child.Value); err != nil {
+		return err
+	}
+	ctx := &FilterCtx{Current: child.Value}
+	for _, st := range states {
+		for _, filter := range r.path.segments[st].filters {
+			if toBool(filter(ctx)) {
+				next = append(next, st+1)
+			}
+		}
+	}
+	return r.evaluate(child, next)
+}
+
+func (r *streamRun) evaluate(node *Node, states []int) error {
+	for _, st := range states {
+		if !r.path.rest[st](node, r.env, r.yield) {
+			return errStreamStopped
+		}
+	}
+	return nil
+}
+
+func (r *streamRun) mustBuffer(states []int) bool {
+	for _, st := range states {
+		if st == len(r.path.segments) || r.path.segments[st].buffer {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *streamRun) skip() error {
+	depth := 0
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func streamName(name string) streamKeyFunc {
+	return func(key any) bool {
+		return key == name
+	}
+}
+
+func streamIndex(index int) streamKeyFunc {
+	return func(key any) bool {
+		return key == index
+	}
+}
+
+func streamWildcard(any) bool {
+	return true
+}
+
+func streamSlice(s *SliceExpr) streamKeyFunc {
+	return func(key any) bool {
+		idx, ok := key.(int)
+		if !ok || idx < s.Start || s.HasEnd && idx >= s.End {
+			return false
+		}
+		return (idx-s.Start)%s.Step == 0
+	}
+}
+
+func isStreamableSlice(s *SliceExpr) bool {
+	return s.Step > 0 && s.Start >= 0 && (!s.HasEnd || s.End >= 0)
+}
+
+func referencesRoot(path *PathExpr) bool {
+	for _, sg := range path.Segments {
+		for _, sel := range sg.Selectors {
+			if sel.Kind == SelectorFilter && filterReferencesRoot(sel.Filter) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func filterReferencesRoot(ex FilterExpr) bool {
+	switch v := ex.(type) {
+	case *PathValueExpr:
+		return v.Absolute || referencesRoot(v.Path)
+	case *UnaryExpr:
+		return filterReferencesRoot(v.Expr)
+	case *BinaryExpr:
+		return filterReferencesRoot(v.Left) || filterReferencesRoot(v.Right)
+	case *ArithmeticExpr:
+		return filterReferencesRoot(v.Left) || filterReferencesRoot(v.Right)
+	case *NegateExpr:
+		return filterReferencesRoot(v.Expr)
+	case *FuncExpr:
+		for _, arg := range v.Args {
+			if filterReferencesRoot(arg) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
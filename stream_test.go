@@ -0,0 +1,160 @@
+package jpath_test
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+const streamDoc = `{
+	"meta": {"count": 3, "limit": 10},
+	"items": [
+		{"id": 1, "price": 5, "tags": ["a"]},
+		{"id": 2, "price": 15, "tags": ["b", "c"]},
+		{"id": 3, "price": 25}
+	]
+}`
+
+func TestStreamMatches(t *testing.T) {
+	cases := map[string][]string{
+		"$.meta.count":   {"$['meta']['count']"},
+		"$.items[-1].id": {"$['items'][2]['id']"},
+		"$.items[1:].id": {
+			"$['items'][1]['id']", "$['items'][2]['id']",
+		},
+		"$.items[?@.price > 10].id": {
+			"$['items'][1]['id']", "$['items'][2]['id']",
+		},
+		"$..tags[*]": {
+			"$['items'][0]['tags'][0]",
+			"$['items'][1]['tags'][0]",
+			"$['items'][1]['tags'][1]",
+		},
+		"$.items[?@.price < $.meta.limit].id": {"$['items'][0]['id']"},
+		"$.missing":                           {},
+	}
+	for query, want := range cases {
+		var got []string
+		err := jpath.Stream(
+			query, strings.NewReader(streamDoc), func(n *jpath.Node) bool {
+				got = append(got, n.Path())
+				return true
+			},
+		)
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+		assert.ElementsMatch(t, want, got, query)
+	}
+}
+
+func TestStreamValues(t *testing.T) {
+	var got []any
+	err := jpath.Stream(
+		"$.items[1]", strings.NewReader(streamDoc), func(n *jpath.Node) bool {
+			got = append(got, n.Value)
+			return true
+		},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []any{map[string]any{
+		"id":    float64(2),
+		"price": float64(15),
+		"tags":  []any{"b", "c"},
+	}}, got)
+}
+
+func TestStreamStopsEarly(t *testing.T) {
+	count := 0
+	err := jpath.Stream(
+		"$..*", strings.NewReader(streamDoc), func(*jpath.Node) bool {
+			count++
+			return false
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestStreamMultipleValues(t *testing.T) {
+	path, err := jpath.CompileStream(jpath.MustParse("$.id"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	dec := json.NewDecoder(strings.NewReader(`{"id":1} {"id":2} 3`))
+	var got []any
+	for {
+		err := path.Stream(dec, func(n *jpath.Node) bool {
+			got = append(got, n.Value)
+			return true
+		})
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+	assert.Equal(t, []any{float64(1), float64(2)}, got)
+}
+
+func TestStreamErrors(t *testing.T) {
+	yield := func(*jpath.Node) bool { return true }
+	err := jpath.Stream("$[", strings.NewReader("{}"), yield)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+
+	err = jpath.Stream("$[?missing()]", strings.NewReader("{}"), yield)
+	assert.ErrorIs(t, err, jpath.ErrUnknownFunc)
+
+	err = jpath.Stream("$.a", strings.NewReader(`{"a": `), yield)
+	assert.Error(t, err)
+
+	err = jpath.Stream("$[?$.a]", strings.NewReader(`{"a": `), yield)
+	assert.Error(t, err)
+}
+
+func TestStreamCompliance(t *testing.T) {
+	reg := jpath.NewRegistry()
+	suite := loadComplianceSuite(t)
+	for _, tc := range suite.Tests {
+		if tc.InvalidSelector {
+			continue
+		}
+		ast := reg.MustParse(tc.Selector)
+		buf, err := json.Marshal(tc.Document)
+		if !assert.NoError(t, err) {
+			return
+		}
+		path, err := reg.CompileStream(ast)
+		if !assert.NoError(t, err, tc.Selector) {
+			continue
+		}
+		var got []string
+		err = path.Stream(
+			json.NewDecoder(strings.NewReader(string(buf))),
+			func(n *jpath.Node) bool {
+				got = append(got, n.Path())
+				return true
+			},
+		)
+		if !assert.NoError(t, err, tc.Selector) {
+			continue
+		}
+		want := reg.MustCompileNodes(ast).Locate(tc.Document).Paths()
+		slices.Sort(want)
+		slices.Sort(got)
+		if len(want) == 0 {
+			assert.Empty(t, got, tc.Selector)
+			continue
+		}
+		assert.Equal(t, want, got, tc.Selector)
+	}
+}
@@ -0,0 +1,167 @@
+package jpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// MatchError reports a match that could not be converted to the requested
+// Go type
+type MatchError struct {
+	Path  string       // normalized path of the failed match
+	Index int          // position of the match in the result list
+	Type  reflect.Type // requested type
+	Err   error        // underlying conversion failure
+}
+
+var (
+	// ErrNoMatch indicates a query that must match produced no nodes
+	ErrNoMatch = errors.New("no match")
+
+	// ErrMultipleMatches indicates a query that must match exactly one node
+	// produced more than one
+	ErrMultipleMatches = errors.New("multiple matches")
+
+	// ErrTypeMismatch indicates a matched value is not of the requested type
+	ErrTypeMismatch = errors.New("match has wrong type")
+)
+
+// QueryAs runs a query and asserts every match to T. A nil registry uses
+// the default registry. The error for a match that is not a T is a
+// *MatchError naming that match
+func QueryAs[T any](r *Registry, query string, document any) ([]T, error) {
+	return convertMatches(r, query, document, assertMatch[T])
+}
+
+// DecodeAs runs a query and decodes every match into a T the way
+// encoding/json would unmarshal its JSON representation. A nil registry
+// uses the default registry. The error for a match that cannot be decoded
+// is a *MatchError naming that match
+func DecodeAs[T any](r *Registry, query string, document any) ([]T, error) {
+	return convertMatches(r, query, document, decodeMatch[T](r))
+}
+
+// First runs a query and asserts its first match to T. It returns
+// ErrNoMatch if nothing matches
+func First[T any](r *Registry, query string, document any) (T, error) {
+	var zero T
+	run, err := registryOrDefault(r).compileNodeQuery(query)
+	if err != nil {
+		return zero, err
+	}
+	n, ok := run.First(document)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrNoMatch, query)
+	}
+	return convertNode(n, 0, assertMatch[T])
+}
+
+// Single runs a query and asserts its only match to T. It returns
+// ErrNoMatch if nothing matches and ErrMultipleMatches if more than one
+// node does
+func Single[T any](r *Registry, query string, document any) (T, error) {
+	var zero T
+	run, err := registryOrDefault(r).compileNodeQuery(query)
+	if err != nil {
+		return zero, err
+	}
+	nodes := run.Limit(document, 2)
+	switch len(nodes) {
+	case 0:
+		return zero, fmt.Errorf("%w: %s", ErrNoMatch, query)
+	case 1:
+		return convertNode(nodes[0], 0, assertMatch[T])
+	default:
+		return zero, fmt.Errorf("%w: %s", ErrMultipleMatches, query)
+	}
+}
+
+// Error implements the error interface
+func (e *MatchError) Error() string {
+	return fmt.Sprintf(
+		"match %d at %s: cannot convert to %s: %s",
+		e.Index, e.Path, e.Type, e.Err,
+	)
+}
+
+// Unwrap returns the underlying conversion failure
+func (e *MatchError) Unwrap() error {
+	return e.Err
+}
+
+func convertMatches[T any](
+	r *Registry, query string, document any, convert func(any) (T, error),
+) ([]T, error) {
+	nodes, err := registryOrDefault(r).Locate(query, document)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, len(nodes))
+	for idx, n := range nodes {
+		if res[idx], err = convertNode(n, idx, convert); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func convertNode[T any](
+	n *Node, idx int, convert func(any) (T, error),
+) (T, error) {
+	res, err := convert(n.Value)
+	if err != nil {
+		return res, &MatchError{
+			Path:  n.Path(),
+			Index: idx,
+			Type:  reflect.TypeFor[T](),
+			Err:   err,
+		}
+	}
+	return res, nil
+}
+
+// assertMatch asserts a matched value to T. A JSON null converts to the
+// zero value of any T that can hold nil
+func assertMatch[T any](value any) (T, error) {
+	res, ok := value.(T)
+	if !ok && (value != nil || !isNilable(reflect.TypeFor[T]())) {
+		return res, fmt.Errorf("%w: got %T", ErrTypeMismatch, value)
+	}
+	return res, nil
+}
+
+func isNilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice,
+		reflect.Func, reflect.Chan:
+		return true
+	default:
+		return false
+	}
+}
+
+func decodeMatch[T any](r *Registry) func(any) (T, error) {
+	a := registryOrDefault(r).nodeAdapter()
+	custom := registryOrDefault(r).hasCustomAdapter()
+	return func(value any) (T, error) {
+		var res T
+		if custom {
+			value = toJSON(a, value)
+		}
+		buf, err := json.Marshal(value)
+		if err != nil {
+			return res, err
+		}
+		err = json.Unmarshal(buf, &res)
+		return res, err
+	}
+}
+
+func registryOrDefault(r *Registry) *Registry {
+	if r == nil {
+		return defaultRegistry
+	}
+	return r
+}
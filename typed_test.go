@@ -0,0 +1,133 @@
+package jpath_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kode4food/jpath"
+)
+
+func typedFixture() any {
+	var doc any
+	err := json.Unmarshal([]byte(`{
+		"items": [
+			{"name": "a", "price": 5, "tags": ["x"]},
+			{"name": "b", "price": 15, "tags": ["y", "z"]},
+			{"name": "c", "price": "n/a"}
+		]
+	}`), &doc)
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+func TestQueryAs(t *testing.T) {
+	doc := typedFixture()
+
+	names, err := jpath.QueryAs[string](nil, "$.items[*].name", doc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+
+	_, err = jpath.QueryAs[float64](nil, "$.items[*].price", doc)
+	assert.ErrorIs(t, err, jpath.ErrTypeMismatch)
+	var me *jpath.MatchError
+	if assert.ErrorAs(t, err, &me) {
+		assert.Equal(t, "$['items'][2]['price']", me.Path)
+		assert.Equal(t, 2, me.Index)
+	}
+	assert.EqualError(t, err,
+		"match 2 at $['items'][2]['price']: cannot convert to float64: "+
+			"match has wrong type: got string",
+	)
+
+	_, err = jpath.QueryAs[string](nil, "$[", doc)
+	assert.ErrorIs(t, err, jpath.ErrInvalidPath)
+
+	empty, err := jpath.QueryAs[string](nil, "$.missing", doc)
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestQueryAsNull(t *testing.T) {
+	assertNullAs[any](t)
+	assertNullAs[*string](t)
+	assertNullAs[map[string]any](t)
+	assertNullAs[[]any](t)
+
+	nulls := map[string]any{"a": nil}
+	_, err := jpath.QueryAs[string](nil, "$.a", nulls)
+	assert.ErrorIs(t, err, jpath.ErrTypeMismatch)
+	first, err := jpath.First[any](nil, "$.a", nulls)
+	assert.NoError(t, err)
+	assert.Nil(t, first)
+}
+
+// assertNullAs checks that a JSON null converts to the zero value of T
+func assertNullAs[T any](t *testing.T) {
+	t.Helper()
+	got, err := jpath.QueryAs[T](nil, "$.a", map[string]any{"a": nil})
+	if assert.NoError(t, err) {
+		var zero T
+		assert.Equal(t, []T{zero}, got)
+	}
+}
+
+func TestFirstAndSingle(t *testing.T) {
+	doc := typedFixture()
+
+	first, err := jpath.First[string](nil, "$.items[*].name", doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", first)
+
+	_, err = jpath.First[string](nil, "$.missing", doc)
+	assert.ErrorIs(t, err, jpath.ErrNoMatch)
+
+	price, err := jpath.Single[float64](
+		nil, "$.items[?@.name == 'b'].price", doc,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 15.0, price)
+
+	_, err = jpath.Single[string](nil, "$.items[*].name", doc)
+	assert.ErrorIs(t, err, jpath.ErrMultipleMatches)
+
+	_, err = jpath.Single[string](nil, "$.missing", doc)
+	assert.ErrorIs(t, err, jpath.ErrNoMatch)
+
+	_, err = jpath.Single[string](nil, "$.items[0].price", doc)
+	assert.ErrorIs(t, err, jpath.ErrTypeMismatch)
+}
+
+func TestDecodeAs(t *testing.T) {
+	type item struct {
+		Name  string   `json:"name"`
+		Price int      `json:"price"`
+		Tags  []string `json:"tags"`
+	}
+	doc := typedFixture()
+
+	items, err := jpath.DecodeAs[item](nil, "$.items[:2]", doc)
+	assert.NoError(t, err)
+	assert.Equal(t, []item{
+		{Name: "a", Price: 5, Tags: []string{"x"}},
+		{Name: "b", Price: 15, Tags: []string{"y", "z"}},
+	}, items)
+
+	_, err = jpath.DecodeAs[item](nil, "$.items[*]", doc)
+	var me *jpath.MatchError
+	if assert.ErrorAs(t, err, &me) {
+		assert.Equal(t, "$['items'][2]", me.Path)
+	}
+	var ute *json.UnmarshalTypeError
+	assert.ErrorAs(t, err, &ute)
+
+	reg := jpath.NewRegistry().WithAdapter(orderedAdapter{})
+	ords, err := jpath.DecodeAs[map[string]string](
+		reg, "$.mid", orderedFixture(),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"a": "ay", "b": "bee"}}, ords)
+}
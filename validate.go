@@ -6,9 +6,14 @@ import (
 )
 
 type (
-	exprContext uint8
+	// SpanError locates a validation or compile error at the syntax tree
+	// node that caused it
+	SpanError struct {
+		Span Span
+		Err  error
+	}
 
-	unaryArgValidator func(name string, arg FilterExpr) error
+	exprContext uint8
 )
 
 const (
@@ -51,37 +56,46 @@ var (
 )
 
 func validatePath(path *PathExpr, registry *Registry) error {
+	if errs := pathErrors(path, registry); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// pathErrors returns every validation error in path, including those in
+// the filters of its subqueries, in the order they appear
+func pathErrors(path *PathExpr, registry *Registry) []error {
+	var res []error
 	for _, sg := range path.Segments {
 		for _, sel := range sg.Selectors {
 			if sel.Kind != SelectorFilter {
 				continue
 			}
-			if err := validateExpr(
-				sel.Filter, contextLogical, false,
-				registry,
-			); err != nil {
-				return err
-			}
+			res = append(res, validateExpr(
+				sel.Filter, contextLogical, false, registry,
+			)...)
 		}
 	}
-	return nil
+	return res
 }
 
 func validateExpr(
 	ex FilterExpr, ctx exprContext, inComparison bool, registry *Registry,
-) error {
+) []error {
 	switch v := ex.(type) {
 	case *LiteralExpr:
 		if ctx == contextLogical {
-			return fmt.Errorf("%w", ErrLiteralMustBeCompared)
+			return []error{spanError(v.Span, ErrLiteralMustBeCompared)}
 		}
 		return nil
 
 	case *PathValueExpr:
+		res := pathErrors(v.Path, registry)
 		if inComparison && !isSingularPath(v.Path) {
-			return fmt.Errorf("%w", ErrCompRequiresSingularQuery)
+			err := spanError(v.Span, ErrCompRequiresSingularQuery)
+			res = append([]error{err}, res...)
 		}
-		return nil
+		return res
 
 	case *UnaryExpr:
 		return validateExpr(v.Expr, contextLogical, false, registry)
@@ -89,45 +103,74 @@ func validateExpr(
 	case *BinaryExpr:
 		switch v.Op {
 		case "&&", "||":
-			if err := validateExpr(
-				v.Left, contextLogical, false,
-				registry,
-			); err != nil {
-				return err
-			}
-			return validateExpr(v.Right, contextLogical, false, registry)
+			return append(
+				validateExpr(v.Left, contextLogical, false, registry),
+				validateExpr(v.Right, contextLogical, false, registry)...,
+			)
+
+		case "==", "!=", "<", "<=", ">", ">=",
+			"in", "nin", "subsetof", "anyof":
+			return append(
+				validateExpr(
+					v.Left, contextComparisonOperand, true, registry,
+				),
+				validateExpr(
+					v.Right, contextComparisonOperand, true, registry,
+				)...,
+			)
 
-		case "==", "!=", "<", "<=", ">", ">=":
-			if err := validateExpr(
+		case "=~":
+			res := validateExpr(
 				v.Left, contextComparisonOperand, true, registry,
-			); err != nil {
-				return err
-			}
-			return validateExpr(
-				v.Right, contextComparisonOperand, true,
-				registry,
 			)
+			res = append(res, validateExpr(
+				v.Right, contextComparisonOperand, true, registry,
+			)...)
+			if err := validatePattern(v.Right, true); err != nil {
+				res = append(res, err)
+			}
+			return res
 
 		default:
-			return fmt.Errorf("unknown operator: %s", v.Op)
+			return []error{spanError(
+				v.Span, fmt.Errorf("unknown operator: %s", v.Op),
+			)}
+		}
+
+	case *ArithmeticExpr:
+		var res []error
+		if _, ok := arithmeticOps[v.Op]; !ok {
+			res = append(res, spanError(
+				v.Span, fmt.Errorf("unknown operator: %s", v.Op),
+			))
+		} else if ctx == contextLogical {
+			res = append(res, spanError(v.Span, ErrArithmeticMustBeCompared))
 		}
+		res = append(res, validateArithmetic(v.Left, registry)...)
+		return append(res, validateArithmetic(v.Right, registry)...)
+
+	case *NegateExpr:
+		var res []error
+		if ctx == contextLogical {
+			res = append(res, spanError(v.Span, ErrArithmeticMustBeCompared))
+		}
+		return append(res, validateArithmetic(v.Expr, registry)...)
 
 	case *FuncExpr:
-		if err := validateFunction(v, ctx, inComparison, registry); err != nil {
-			return err
+		var res []error
+		err := validateFunction(v, ctx, inComparison, registry)
+		if err != nil {
+			res = append(res, err)
 		}
 		for _, a := range v.Args {
-			if err := validateExpr(
-				a, contextFunctionArg, false,
-				registry,
-			); err != nil {
-				return err
-			}
+			res = append(res, validateExpr(
+				a, contextFunctionArg, false, registry,
+			)...)
 		}
-		return nil
+		return res
 
 	default:
-		return fmt.Errorf("unknown expression")
+		return []error{fmt.Errorf("unknown expression")}
 	}
 }
 
@@ -136,12 +179,41 @@ func validateFunction(
 ) error {
 	def, ok := registry.function(f.Name)
 	if !ok {
-		return fmt.Errorf("%w: %s", ErrUnknownFunc, f.Name)
+		return spanError(f.Span, fmt.Errorf("%w: %s", ErrUnknownFunc, f.Name))
+	}
+	if def.Signature != nil {
+		if err := def.Signature.check(f, ctx, registry); err != nil {
+			return spanError(f.Span, err)
+		}
 	}
 	if def.Validate == nil {
 		return nil
 	}
-	return def.Validate(f.Args, functionUse(ctx), inComparison)
+	err := def.Validate(f.Args, functionUse(ctx), inComparison)
+	if err != nil {
+		return spanError(f.Span, err)
+	}
+	return nil
+}
+
+// Error implements the error interface
+func (e *SpanError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the located error
+func (e *SpanError) Unwrap() error {
+	return e.Err
+}
+
+// spanError locates err at sp, unless it has already been located at a
+// more specific node
+func spanError(sp Span, err error) error {
+	var se *SpanError
+	if errors.As(err, &se) {
+		return err
+	}
+	return &SpanError{Span: sp, Err: err}
 }
 
 func functionUse(ctx exprContext) FunctionUse {
@@ -170,84 +242,9 @@ func isSingularPath(path *PathExpr) bool {
 	return true
 }
 
-func validateMatchSearchFunction(
-	args []FilterExpr, _ FunctionUse, inComparison bool,
-) error {
-	if err := validateFunctionArity("match/search", args, 2); err != nil {
-		return err
-	}
-	if inComparison {
-		return fmt.Errorf(
-			"%w: match/search",
-			ErrFuncResultMustNotBeCompared,
-		)
-	}
-	return nil
-}
-
-func validateUnaryCompared(
-	name string, args []FilterExpr, use FunctionUse, inComparison bool,
-	argValidator unaryArgValidator,
-) error {
-	if err := validateFunctionArity(name, args, 1); err != nil {
-		return err
-	}
-	if err := validateComparedUse(name, use, inComparison); err != nil {
-		return err
-	}
-	return argValidator(name, args[0])
-}
-
-func validateUnaryComparedReq(
-	name string, args []FilterExpr, use FunctionUse, inComparison bool,
-) error {
-	return validateUnaryCompared(
-		name, args, use, inComparison, validateQueryArg,
-	)
-}
-
-func validateUnaryComparedSingular(
-	name string, args []FilterExpr, use FunctionUse, inComparison bool,
-) error {
-	return validateUnaryCompared(
-		name, args, use, inComparison, validateSingularQueryArg,
-	)
-}
-
 func validateFunctionArity(name string, args []FilterExpr, want int) error {
 	if len(args) == want {
 		return nil
 	}
 	return fmt.Errorf("%w: %s", ErrInvalidFuncArity, name)
 }
-
-func validateComparedUse(
-	name string, use FunctionUse, inComparison bool,
-) error {
-	if inComparison || use != FunctionUseLogical {
-		return nil
-	}
-	return fmt.Errorf("%w: %s", ErrFuncResultMustBeCompared, name)
-}
-
-func validateQueryArg(name string, arg FilterExpr) error {
-	if _, ok := arg.(*PathValueExpr); ok {
-		return nil
-	}
-	return fmt.Errorf(
-		"%w: %s requires query argument",
-		ErrFuncRequiresQueryArgument,
-		name,
-	)
-}
-
-func validateSingularQueryArg(name string, arg FilterExpr) error {
-	pv, ok := arg.(*PathValueExpr)
-	if !ok {
-		return nil
-	}
-	if isSingularPath(pv.Path) {
-		return nil
-	}
-	return fmt.Errorf("%w: %s", ErrFuncRequiresSingularQuery, name)
-}
@@ -13,6 +13,11 @@ type (
 
 var nothing nothingType
 
+// Nothing is the scalar of a Value that has no result, such as a ValueType
+// argument whose query matched no nodes. An Evaluator that can't produce a
+// result returns ScalarValue(Nothing)
+var Nothing any = nothing
+
 // ScalarValue constructs a scalar filter value
 func ScalarValue(value any) *Value {
 	return &Value{Scalar: value}
@@ -0,0 +1,325 @@
+// Package yamldoc queries YAML documents with jpath
+//
+// Documents are parsed into yaml.v3 node trees and navigated through
+// Adapter, so object members keep their source order, aliases resolve to
+// their anchored nodes, and every match can be traced back to a line and
+// column in the source
+package yamldoc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kode4food/jpath"
+)
+
+type (
+	adapter struct{}
+
+	aliasState uint8
+)
+
+const (
+	tagNull  = "!!null"
+	tagBool  = "!!bool"
+	tagInt   = "!!int"
+	tagFloat = "!!float"
+	tagMerge = "!!merge"
+)
+
+const (
+	aliasUnchecked aliasState = iota
+	aliasActive
+	aliasChecked
+)
+
+// Adapter navigates *yaml.Node trees. Install it with Registry.WithAdapter
+var Adapter jpath.Adapter = adapter{}
+
+// ErrRecursiveAlias indicates an alias that refers to a node containing it
+var ErrRecursiveAlias = errors.New("recursive YAML alias")
+
+// Parse parses a single YAML document
+func Parse(data []byte) (*yaml.Node, error) {
+	docs, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, io.EOF
+	}
+	return docs[0], nil
+}
+
+// Decode parses every document in a YAML stream
+func Decode(r io.Reader) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(r)
+	var res []*yaml.Node
+	for {
+		doc := &yaml.Node{}
+		err := dec.Decode(doc)
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = checkAliases(doc, map[*yaml.Node]aliasState{})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, doc)
+	}
+}
+
+// Position returns the source line and column of a matched value, which
+// is available when the value is a *yaml.Node
+func Position(value any) (line, column int, ok bool) {
+	n, ok := value.(*yaml.Node)
+	if !ok {
+		return 0, 0, false
+	}
+	return n.Line, n.Column, true
+}
+
+// Value converts a matched node into plain Go values. Mappings become
+// map[string]any, sequences []any, and scalars are typed by their resolved
+// tag: integers as int, floats as float64, and booleans and nulls as bool
+// and nil. Any other scalar, including timestamps, is returned as a string
+func Value(value any) any {
+	n, ok := value.(*yaml.Node)
+	if !ok {
+		return value
+	}
+	n = resolve(n)
+	switch n.Kind {
+	case yaml.MappingNode:
+		keys := Adapter.Keys(n)
+		res := make(map[string]any, len(keys))
+		for _, key := range keys {
+			member, _ := Adapter.Member(n, key)
+			res[key] = Value(member)
+		}
+		return res
+	case yaml.SequenceNode:
+		res := make([]any, len(n.Content))
+		for idx, elem := range n.Content {
+			res[idx] = Value(elem)
+		}
+		return res
+	default:
+		return scalarValue(n)
+	}
+}
+
+func (adapter) Kind(node any) jpath.NodeKind {
+	n, ok := node.(*yaml.Node)
+	if !ok {
+		return jpath.NodeOther
+	}
+	n = resolve(n)
+	switch n.Kind {
+	case yaml.MappingNode:
+		return jpath.NodeObject
+	case yaml.SequenceNode:
+		return jpath.NodeArray
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case tagNull:
+			return jpath.NodeNull
+		case tagBool:
+			return jpath.NodeBool
+		case tagInt, tagFloat:
+			return jpath.NodeNumber
+		default:
+			return jpath.NodeString
+		}
+	default:
+		return jpath.NodeNull
+	}
+}
+
+func (adapter) Member(node any, name string) (any, bool) {
+	n, ok := mapping(node)
+	if !ok {
+		return nil, false
+	}
+	var merged []*yaml.Node
+	for idx := 0; idx+1 < len(n.Content); idx += 2 {
+		key := resolve(n.Content[idx])
+		if key.ShortTag() == tagMerge {
+			merged = append(merged, n.Content[idx+1])
+			continue
+		}
+		if key.Value == name {
+			return n.Content[idx+1], true
+		}
+	}
+	for _, m := range mergeSources(merged) {
+		if value, ok := Adapter.Member(m, name); ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (adapter) Keys(node any) []string {
+	n, ok := mapping(node)
+	if !ok {
+		return nil
+	}
+	var res []string
+	var merged []*yaml.Node
+	seen := map[string]bool{}
+	for idx := 0; idx+1 < len(n.Content); idx += 2 {
+		key := resolve(n.Content[idx])
+		if key.ShortTag() == tagMerge {
+			merged = append(merged, n.Content[idx+1])
+			continue
+		}
+		if !seen[key.Value] {
+			seen[key.Value] = true
+			res = append(res, key.Value)
+		}
+	}
+	for _, m := range mergeSources(merged) {
+		for _, key := range Adapter.Keys(m) {
+			if !seen[key] {
+				seen[key] = true
+				res = append(res, key)
+			}
+		}
+	}
+	return res
+}
+
+func (adapter) Len(node any) int {
+	return len(resolve(node.(*yaml.Node)).Content)
+}
+
+func (adapter) Index(node any, idx int) any {
+	return resolve(node.(*yaml.Node)).Content[idx]
+}
+
+// Scalar returns the value used in comparisons. Numbers are always
+// float64 here, so that integers compare equal to query literals
+func (adapter) Scalar(node any) any {
+	n, ok := node.(*yaml.Node)
+	if !ok {
+		return node
+	}
+	switch v := scalarValue(resolve(n)).(type) {
+	case int:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// mapping returns the mapping node that a node stands for, if it is one
+func mapping(node any) (*yaml.Node, bool) {
+	n, ok := node.(*yaml.Node)
+	if !ok {
+		return nil, false
+	}
+	n = resolve(n)
+	return n, n.Kind == yaml.MappingNode
+}
+
+// resolve follows document wrappers and aliases to the node they stand for
+func resolve(n *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case n.Kind == yaml.DocumentNode && len(n.Content) == 1:
+			n = n.Content[0]
+		case n.Kind == yaml.AliasNode && n.Alias != nil:
+			n = n.Alias
+		default:
+			return n
+		}
+	}
+}
+
+// mergeSources flattens the values of merge keys into the mappings they
+// merge, in priority order
+func mergeSources(values []*yaml.Node) []*yaml.Node {
+	var res []*yaml.Node
+	for _, v := range values {
+		v = resolve(v)
+		switch v.Kind {
+		case yaml.MappingNode:
+			res = append(res, v)
+		case yaml.SequenceNode:
+			for _, elem := range v.Content {
+				if elem = resolve(elem); elem.Kind == yaml.MappingNode {
+					res = append(res, elem)
+				}
+			}
+		}
+	}
+	return res
+}
+
+func scalarValue(n *yaml.Node) any {
+	if n.Kind != yaml.ScalarNode {
+		return nil
+	}
+	switch n.ShortTag() {
+	case tagNull:
+		return nil
+	case tagBool:
+		var res bool
+		if n.Decode(&res) == nil {
+			return res
+		}
+	case tagInt:
+		var res int64
+		err := n.Decode(&res)
+		if err == nil && res >= math.MinInt && res <= math.MaxInt {
+			return int(res)
+		}
+		var big float64
+		if n.Decode(&big) == nil {
+			return big
+		}
+	case tagFloat:
+		var res float64
+		if n.Decode(&res) == nil {
+			return res
+		}
+		if f, err := strconv.ParseFloat(n.Value, 64); err == nil {
+			return f
+		}
+	}
+	return n.Value
+}
+
+// checkAliases rejects aliases whose anchored node contains the alias.
+// Each node is checked once, however many aliases refer to it
+func checkAliases(n *yaml.Node, state map[*yaml.Node]aliasState) error {
+	switch state[n] {
+	case aliasActive:
+		return fmt.Errorf("%w at line %d", ErrRecursiveAlias, n.Line)
+	case aliasChecked:
+		return nil
+	}
+	state[n] = aliasActive
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		if err := checkAliases(n.Alias, state); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.Content {
+		if err := checkAliases(child, state); err != nil {
+			return err
+		}
+	}
+	state[n] = aliasChecked
+	return nil
+}
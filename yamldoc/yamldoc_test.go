@@ -0,0 +1,140 @@
+package yamldoc_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kode4food/jpath"
+	"github.com/kode4food/jpath/yamldoc"
+)
+
+const deployment = `kind: Deployment
+defaults: &defaults
+  replicas: 2
+  image: app:1.0
+spec:
+  <<: *defaults
+  replicas: 3
+  ratio: 0.5
+  enabled: true
+  nothing: ~
+  when: 2024-01-02
+  containers:
+    - name: web
+      port: 8080
+    - name: sidecar
+      port: 9090
+  primary: *defaults
+`
+
+func parse(t *testing.T, src string) *yaml.Node {
+	t.Helper()
+	doc, err := yamldoc.Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestQueryYAML(t *testing.T) {
+	reg := jpath.NewRegistry().WithAdapter(yamldoc.Adapter)
+	doc := parse(t, deployment)
+
+	nodes := reg.MustLocate("$.spec.containers[?@.port > 9000].name", doc)
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, "sidecar", yamldoc.Value(nodes[0].Value))
+		line, col, ok := yamldoc.Position(nodes[0].Value)
+		assert.True(t, ok)
+		assert.Equal(t, 15, line)
+		assert.Equal(t, 13, col)
+		assert.Equal(t, "$['spec']['containers'][1]['name']", nodes[0].Path())
+	}
+
+	got := reg.MustQuery("$.spec.containers[?@.name == 'web'].port", doc)
+	assert.Equal(t, []any{8080}, values(got))
+
+	got = reg.MustQuery("$.spec.*", doc)
+	assert.Len(t, got, 8)
+	assert.Equal(t,
+		[]any{3, 0.5, true, nil, "2024-01-02"}, values(got[:5]),
+	)
+
+	got = reg.MustQuery("$.spec.image", doc)
+	assert.Equal(t, []any{"app:1.0"}, values(got))
+
+	got = reg.MustQuery("$.spec.primary.replicas", doc)
+	assert.Equal(t, []any{2}, values(got))
+
+	got = reg.MustQuery("$[?length(@.containers) == 2].replicas", doc)
+	assert.Equal(t, []any{3}, values(got))
+
+	got = reg.MustQuery("$[?@.replicas == 3].ratio", doc)
+	assert.Equal(t, []any{0.5}, values(got))
+}
+
+func TestKeyOrder(t *testing.T) {
+	reg := jpath.NewRegistry().WithAdapter(yamldoc.Adapter)
+	doc := parse(t, "z: 1\na: 2\nm: 3\n")
+
+	paths := reg.MustLocate("$.*", doc).Paths()
+	assert.Equal(t, []string{"$['z']", "$['a']", "$['m']"}, paths)
+}
+
+func TestMismatchedKinds(t *testing.T) {
+	reg := jpath.NewRegistry().WithAdapter(yamldoc.Adapter)
+	doc := parse(t, "items: [foo, bar]\n")
+
+	assert.Empty(t, reg.MustQuery("$.items.foo", doc))
+	assert.Empty(t, reg.MustQuery("$.items[0].foo", doc))
+	assert.Empty(t, reg.MustQuery("$.plain", "plain string"))
+	assert.Empty(t, reg.MustQuery("$.*", "plain string"))
+
+	_, ok := yamldoc.Adapter.Member(parse(t, "[foo, bar]\n"), "foo")
+	assert.False(t, ok)
+	_, ok = yamldoc.Adapter.Member("plain", "foo")
+	assert.False(t, ok)
+	assert.Nil(t, yamldoc.Adapter.Keys(parse(t, "[a, b]\n")))
+	assert.Nil(t, yamldoc.Adapter.Keys("plain"))
+}
+
+func TestValue(t *testing.T) {
+	doc := parse(t, deployment)
+
+	spec := yamldoc.Value(doc).(map[string]any)["spec"].(map[string]any)
+	assert.Equal(t, 3, spec["replicas"])
+	assert.Equal(t, "app:1.0", spec["image"])
+	assert.Equal(t, []any{
+		map[string]any{"name": "web", "port": 8080},
+		map[string]any{"name": "sidecar", "port": 9090},
+	}, spec["containers"])
+	assert.Equal(t, "plain", yamldoc.Value("plain"))
+
+	_, _, ok := yamldoc.Position("plain")
+	assert.False(t, ok)
+}
+
+func TestDecode(t *testing.T) {
+	docs, err := yamldoc.Decode(strings.NewReader("a: 1\n---\na: 2\n"))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 2)
+
+	_, err = yamldoc.Parse([]byte("a: [1"))
+	assert.Error(t, err)
+
+	_, err = yamldoc.Parse([]byte(""))
+	assert.Error(t, err)
+
+	_, err = yamldoc.Parse([]byte("a: &x [*x]\n"))
+	assert.ErrorIs(t, err, yamldoc.ErrRecursiveAlias)
+}
+
+func values(nodes []any) []any {
+	res := make([]any, len(nodes))
+	for idx, n := range nodes {
+		res[idx] = yamldoc.Value(n)
+	}
+	return res
+}